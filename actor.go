@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type filmographyResponse struct {
+	Name   string         `json:"name" example:"Tom Hanks"`
+	Type   string         `json:"type,omitempty"`
+	Count  int            `json:"count"`
+	Total  int            `json:"total"`
+	Movies []movieSummary `json:"movies"`
+}
+
+// newestFirst orders a filmography by year, undated titles last.
+var newestFirst = byKey(sortKeys["year"], true, missingLast)
+
+// filmographyHandler is GET /api/actor/filmography, the titles crediting an
+// actor, newest first. OMDb has no person lookup, so this searches for the
+// name and keeps the hits whose Actors list has it as an entry (ignoring
+// case and extra spaces), never a substring. Every title returned really
+// credits the actor, but only ones the search index turns up for the name
+// are found, so the list is best-effort and usually incomplete.
+//
+//	@Summary	An actor's movies
+//	@Tags		movies
+//	@Produce	json,xml
+//	@Param		name	query		string	true	"Actor, e.g. Tom Hanks"
+//	@Param		limit	query		int		false	"Movies to return, at most 100"	default(15)
+//	@Param		type	query		string	false	"Restrict to a type"	Enums(movie, series, episode)
+//	@Success	200		{object}	filmographyResponse
+//	@Failure	400		{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	429		{object}	errorResponse	"QUOTA_EXCEEDED"
+//	@Failure	503		{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504		{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router		/api/actor/filmography [get]
+func filmographyHandler(c *gin.Context) {
+	if !requireParams(c, "name") {
+		return
+	}
+	name := strings.Join(strings.Fields(c.Query("name")), " ")
+	limit := defaultGenreLimit
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondParamError(c, &paramError{"limit", l, "a positive integer"})
+			return
+		}
+		limit = min(n, maxGenreLimit)
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	var stats collectStats
+	actors := func(m *Movie) string { return m.Actors }
+	top := collectTopByPerson(c.Request.Context(), name, actors, limit, crawlOpts{limit: genreCrawlLimit, rank: newestFirst, typ: typ, stats: &stats})
+	if timedOut(c) {
+		return
+	}
+	if stats.stopped != nil && len(top) == 0 {
+		respondFetchError(c, stats.stopped, "")
+		return
+	}
+	out := make([]movieSummary, 0, len(top))
+	for _, m := range top {
+		out = append(out, summarize(m))
+	}
+	respond(c, 200, filmographyResponse{Name: name, Type: typ, Count: len(out), Total: stats.kept(), Movies: out})
+}
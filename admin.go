@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminToken guards /api/admin/*, set from ADMIN_TOKEN. The admin routes
+// aren't registered at all when it is empty.
+var adminToken string
+
+// warmWorkers caps warming across all jobs so a large warm can't crowd out
+// user traffic; every call still goes through omdbLimiter.
+const warmWorkers = 2
+
+// warmGenreBudget and warmDeadline bound the work of one warm job.
+const warmGenreBudget = 100
+const warmDeadline = 10 * time.Minute
+
+var warmSem = make(chan struct{}, warmWorkers)
+
+type warmRequest struct {
+	Titles []string `json:"titles"`
+	Genres []string `json:"genres"`
+}
+
+// requireAdmin accepts "Authorization: Bearer <ADMIN_TOKEN>".
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(adminToken)) != 1 {
+			respondError(c, 401, codeUnauthorized, "invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// warmHandler queues the titles and genres for fetching in the background
+// and returns straight away with the job id used in its log lines.
+//
+//	@Summary	Warm the detail cache in the background
+//	@Description	Only registered when ADMIN_TOKEN is set.
+//	@Tags	admin
+//	@Accept	json
+//	@Produce	json
+//	@Security	AdminToken && APIKey
+//	@Param	body	body	warmRequest	true	"Titles and genres to fetch, at most 50 entries"
+//	@Success	202	{object}	warmResponse
+//	@Failure	400	{object}	errorResponse	"INVALID_BODY"
+//	@Failure	401	{object}	errorResponse	"UNAUTHORIZED"
+//	@Router	/api/admin/warm [post]
+func warmHandler(c *gin.Context) {
+	var req warmRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Titles)+len(req.Genres) == 0 {
+		respondError(c, 400, codeInvalidBody, "body must be {\"titles\": [...]} or {\"genres\": [...]}")
+		return
+	}
+	if len(req.Titles)+len(req.Genres) > maxBatchSize {
+		respondError(c, 400, codeInvalidBody, fmt.Sprintf("at most %d entries per warm", maxBatchSize))
+		return
+	}
+	id := newRequestID()
+	go runWarm(id, req)
+	c.JSON(202, warmResponse{id, "queued", len(req.Titles), len(req.Genres)})
+}
+
+func runWarm(id string, req warmRequest) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestIDKey, id), warmDeadline)
+	defer cancel()
+	start := time.Now()
+	var mu sync.Mutex
+	warmed, failed := 0, 0
+	var wg sync.WaitGroup
+	run := func(f func() bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case warmSem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			ok := f()
+			<-warmSem
+			mu.Lock()
+			if ok {
+				warmed++
+			} else {
+				failed++
+			}
+			mu.Unlock()
+		}()
+	}
+	for _, t := range req.Titles {
+		run(func() bool {
+			_, err := getDetailByTitle(ctx, t, "")
+			return err == nil
+		})
+	}
+	for _, g := range req.Genres {
+		run(func() bool {
+			n := 0
+			walkGenre(ctx, g, crawlOpts{limit: genreCrawlLimit, budget: warmGenreBudget}, func(*Movie) { n++ })
+			return n > 0
+		})
+	}
+	wg.Wait()
+	logEvent(ctx, map[string]interface{}{
+		"level":     "info",
+		"msg":       "warm finished",
+		"warmed":    warmed,
+		"failed":    failed,
+		"timed_out": ctx.Err() != nil,
+		"took_ms":   time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,233 @@
+package main
+
+import "encoding/xml"
+
+// Response bodies, one type per shape a handler writes. They double as the
+// schema of the generated OpenAPI spec (see docs/), so field tags here are
+// the public contract.
+
+// errorResponse is the body of every JSON error.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code    errCode     `json:"code" enums:"MISSING_PARAM,INVALID_PARAM,INVALID_BODY,NOT_FOUND,UPSTREAM_ERROR,RATE_LIMITED,QUOTA_EXCEEDED,TIMEOUT,UNAUTHORIZED,UNSUPPORTED_FORMAT,INTERNAL_ERROR"`
+	Message string      `json:"message" example:"movie not found"`
+	Details interface{} `json:"details,omitempty" swaggertype:"object"`
+}
+
+// movieResponse is /api/movie and /api/movie/id/:imdbID. Ratings is OMDb's
+// array, or normRatings with normalize=true. The pointer strings are null
+// where OMDb says "N/A".
+type movieResponse struct {
+	XMLName        xml.Name    `json:"-" xml:"response"`
+	Title          string      `json:"Title" xml:"Title" example:"Inception"`
+	Year           string      `json:"Year" xml:"Year" example:"2010"`
+	Rated          *string     `json:"Rated" xml:"Rated" example:"PG-13"`
+	Released       *string     `json:"Released" xml:"Released" example:"16 Jul 2010"`
+	Writer         *string     `json:"Writer" xml:"Writer" example:"Christopher Nolan"`
+	Language       *string     `json:"Language" xml:"Language" example:"English, Japanese, French"`
+	Production     *string     `json:"Production" xml:"Production"`
+	Plot           string      `json:"Plot" xml:"Plot"`
+	Country        string      `json:"Country" xml:"Country"`
+	Awards         string      `json:"Awards" xml:"Awards"`
+	Director       string      `json:"Director" xml:"Director"`
+	Ratings        interface{} `json:"Ratings" xml:"Ratings>item" swaggertype:"array,object"`
+	Poster         string      `json:"Poster" xml:"Poster"`
+	Runtime        *string     `json:"Runtime" xml:"Runtime" example:"148 min"`
+	RuntimeMinutes *int        `json:"RuntimeMinutes" xml:"RuntimeMinutes" example:"148"`
+	RottenTomatoes *int        `json:"RottenTomatoes" xml:"RottenTomatoes" example:"87"`
+	Metacritic     *int        `json:"Metacritic" xml:"Metacritic" example:"74"`
+	BoxOffice      *string     `json:"BoxOffice" xml:"BoxOffice" example:"$292,587,330"`
+	BoxOfficeUSD   *int64      `json:"BoxOfficeUSD" xml:"BoxOfficeUSD" example:"292587330"`
+}
+
+// movieLookupResponse is /api/movie: the movie, and whether the title
+// matched exactly or was the best of a search, with the runners-up.
+type movieLookupResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	movieResponse
+	MatchedExactly bool       `json:"matchedExactly" xml:"matchedExactly"`
+	DidYouMean     []titleRef `json:"didYouMean,omitempty" xml:"didYouMean>item,omitempty"`
+}
+
+type titleRef struct {
+	Title  string `json:"Title" xml:"Title"`
+	Year   string `json:"Year" xml:"Year"`
+	ImdbID string `json:"imdbID" xml:"imdbID"`
+}
+
+type episodeResponse struct {
+	Title      string `json:"Title"`
+	Season     string `json:"Season"`
+	Episode    string `json:"Episode"`
+	Released   string `json:"Released"`
+	Plot       string `json:"Plot"`
+	ImdbRating string `json:"imdbRating"`
+	Poster     string `json:"Poster"`
+}
+
+type seasonResponse struct {
+	Title        string          `json:"Title"`
+	Season       string          `json:"Season"`
+	TotalSeasons string          `json:"totalSeasons"`
+	Count        int             `json:"count"`
+	Episodes     []seasonEpisode `json:"episodes"`
+}
+
+// seriesResponse is /api/series without a season. totalSeasons is null
+// when OMDb doesn't know it.
+type seriesResponse struct {
+	Title        string `json:"Title"`
+	Year         string `json:"Year" example:"2008–2013"`
+	ImdbID       string `json:"imdbID"`
+	TotalSeasons *int   `json:"totalSeasons" example:"5"`
+	Genre        string `json:"Genre"`
+	Plot         string `json:"Plot"`
+	ImdbRating   string `json:"imdbRating"`
+}
+
+type searchResponse struct {
+	Query        string       `json:"query"`
+	Page         int          `json:"page"`
+	Depth        int          `json:"depth"`
+	TotalResults int          `json:"totalResults"`
+	TotalPages   int          `json:"totalPages"`
+	HasMore      bool         `json:"hasMore"`
+	Count        int          `json:"count"`
+	Results      []searchItem `json:"results"`
+	Sort         string       `json:"sort,omitempty"`
+	Order        string       `json:"order,omitempty"`
+}
+
+// movieSummary is a movie as list endpoints show it.
+type movieSummary struct {
+	Title        string `json:"Title"`
+	Year         string `json:"Year"`
+	ImdbID       string `json:"imdbID"`
+	Genre        string `json:"Genre"`
+	ImdbRating   string `json:"imdbRating"`
+	TotalSeasons string `json:"totalSeasons,omitempty"`
+}
+
+// summarize is m as a list item; totalSeasons is only set for series.
+func summarize(m *Movie) movieSummary {
+	s := movieSummary{Title: m.Title, Year: m.Year, ImdbID: m.ImdbID, Genre: m.Genre, ImdbRating: m.ImdbRating}
+	if m.Type == "series" && m.TotalSeasons != "" && m.TotalSeasons != "N/A" {
+		s.TotalSeasons = m.TotalSeasons
+	}
+	return s
+}
+
+type genreResponse struct {
+	Genre       string            `json:"genre"`
+	Genres      []string          `json:"genres" example:"action,sci-fi"`
+	Mode        string            `json:"mode" enums:"any,all"`
+	Type        string            `json:"type,omitempty"`
+	Filters     map[string]string `json:"filters"`
+	Sort        string            `json:"sort"`
+	Order       string            `json:"order"`
+	Offset      int               `json:"offset"`
+	Page        int               `json:"page"`
+	PageSize    int               `json:"page_size"`
+	Count       int               `json:"count"`
+	Total       int               `json:"total"`
+	TotalPages  int               `json:"total_pages"`
+	Movies      []movieSummary    `json:"movies"`
+	Diagnostics *collectStats     `json:"diagnostics,omitempty"`
+}
+
+type recommendItem struct {
+	movieSummary
+	Director       string     `json:"Director"`
+	Actors         string     `json:"Actors"`
+	Poster         string     `json:"Poster"`
+	RottenTomatoes *int       `json:"RottenTomatoes" example:"87"`
+	Metacritic     *int       `json:"Metacritic" example:"74"`
+	Reason         string     `json:"reason" example:"Directed by Christopher Nolan"`
+	ReasonCode     reasonCode `json:"reasonCode" enums:"GENRE_MATCH,SAME_DIRECTOR,SHARED_ACTOR,POPULAR_FALLBACK"`
+	Matched        string     `json:"matched"`
+}
+
+// seedMatch is how a recommend seed given by title was resolved. Score is
+// titleSimilarity of the query and the title found, 1 for an exact match.
+type seedMatch struct {
+	Query  string  `json:"query" example:"inceptoin"`
+	Title  string  `json:"Title" example:"Inception"`
+	ImdbID string  `json:"imdbID" example:"tt1375666"`
+	Score  float64 `json:"score" example:"0.78"`
+}
+
+type recommendResponse struct {
+	FavoriteMovie   string          `json:"favorite_movie"`
+	SeedMatch       *seedMatch      `json:"seed_match,omitempty"`
+	Recommendations []recommendItem `json:"recommendations"`
+}
+
+// batchItem is one entry of a batch lookup: the movie when found, else error.
+type batchItem struct {
+	Query string    `json:"query"`
+	Found bool      `json:"found"`
+	Error *apiError `json:"error,omitempty"`
+	*batchMovie
+}
+
+type batchMovie struct {
+	movieSummary
+	Director       string `json:"Director"`
+	RottenTomatoes *int   `json:"RottenTomatoes" example:"87"`
+	Metacritic     *int   `json:"Metacritic" example:"74"`
+}
+
+type batchResponse struct {
+	Count   int         `json:"count"`
+	Results []batchItem `json:"results"`
+}
+
+type watchAddResponse struct {
+	Added bool      `json:"added"`
+	Item  watchItem `json:"item"`
+}
+
+// watchEntry is a saved title; the detail fields are only set when hydrated.
+type watchEntry struct {
+	ImdbID       string `json:"imdbID"`
+	AddedAt      string `json:"addedAt"`
+	Title        string `json:"Title"`
+	Year         string `json:"Year"`
+	Poster       string `json:"Poster"`
+	Hydrated     bool   `json:"hydrated"`
+	Genre        string `json:"Genre,omitempty"`
+	Director     string `json:"Director,omitempty"`
+	Runtime      string `json:"Runtime,omitempty"`
+	ImdbRating   string `json:"imdbRating,omitempty"`
+	TotalSeasons string `json:"totalSeasons,omitempty"`
+}
+
+type watchlistResponse struct {
+	Count int          `json:"count"`
+	Items []watchEntry `json:"items"`
+}
+
+type healthResponse struct {
+	Status         string        `json:"status" enums:"ok,unavailable"`
+	Error          string        `json:"error,omitempty"`
+	OMDBLatencyMs  int64         `json:"omdb_latency_ms"`
+	CheckedAt      string        `json:"checked_at"`
+	OMDBRateTokens float64       `json:"omdb_rate_tokens"`
+	Breaker        breakerStatus `json:"breaker"`
+}
+
+// statusResponse is the body of the liveness and readiness probes.
+type statusResponse struct {
+	Status string `json:"status" enums:"ok,unavailable"`
+	Error  string `json:"error,omitempty"`
+}
+
+type warmResponse struct {
+	Job    string `json:"job"`
+	Status string `json:"status" example:"queued"`
+	Titles int    `json:"titles"`
+	Genres int    `json:"genres"`
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxBatchSize = 50
+const batchWorkers = 5
+
+type batchRequest struct {
+	Titles []string `json:"titles"`
+	IDs    []string `json:"ids"`
+}
+
+// batchMoviesHandler looks up many titles or imdbIDs at once. Results keep
+// the request order and an entry that can't be found doesn't fail the others.
+//
+//	@Summary	Look up many movies at once
+//	@Description	Also served at POST /api/movies.
+//	@Tags	movies
+//	@Accept	json
+//	@Produce	json,xml
+//	@Param	body	body	batchRequest	true	"Exactly one of titles or ids, at most 50 entries"
+//	@Success	200	{object}	batchResponse
+//	@Failure	400	{object}	errorResponse	"INVALID_BODY"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/movies/batch [post]
+func batchMoviesHandler(c *gin.Context) {
+	var req batchRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.Titles) == 0) == (len(req.IDs) == 0) {
+		respondError(c, 400, codeInvalidBody, "body must be {\"titles\": [...]} or {\"ids\": [...]}")
+		return
+	}
+	queries := req.Titles
+	lookup := func(ctx context.Context, t string) (*Movie, error) { return getDetailByTitle(ctx, t, "") }
+	if len(req.IDs) > 0 {
+		queries, lookup = req.IDs, getDetailByID
+	}
+	if len(queries) > maxBatchSize {
+		respondError(c, 400, codeInvalidBody, fmt.Sprintf("at most %d entries per batch", maxBatchSize))
+		return
+	}
+	ctx := c.Request.Context()
+	out := make([]batchItem, len(queries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < batchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				t := queries[i]
+				if len(req.IDs) > 0 && !imdbIDPattern.MatchString(t) {
+					out[i] = batchItem{Query: t, Error: batchError(codeInvalidParam, "invalid imdbID")}
+					continue
+				}
+				m, err := lookup(ctx, t)
+				if err != nil {
+					out[i] = batchItem{Query: t, Error: itemError(err)}
+					continue
+				}
+				bm := &batchMovie{movieSummary: summarize(m), Director: m.Director}
+				bm.RottenTomatoes, bm.Metacritic = ratingScores(m.Ratings)
+				out[i] = batchItem{Query: t, Found: true, batchMovie: bm}
+			}
+		}()
+	}
+	for i := range queries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if timedOut(c) {
+		return
+	}
+	respond(c, 200, batchResponse{Count: len(out), Results: out})
+}
+
+func batchError(code errCode, msg string) *apiError {
+	return &apiError{Code: code, Message: msg}
+}
+
+// itemError is the per-entry error of a failed lookup, in the same shape as
+// a whole-request error.
+func itemError(err error) *apiError {
+	switch {
+	case errors.Is(err, errNotFound):
+		return batchError(codeNotFound, "not found")
+	case errors.Is(err, errQuotaExceeded):
+		return batchError(codeQuotaExceeded, "OMDb daily request limit reached")
+	case errors.Is(err, errRateLimited):
+		return batchError(codeRateLimited, "OMDb rate limit reached, retry later")
+	case errors.Is(err, context.DeadlineExceeded):
+		return batchError(codeTimeout, "timed out waiting for OMDb")
+	}
+	return batchError(codeUpstream, "OMDb lookup failed")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errCircuitOpen = errors.New("omdb circuit open")
+
+// breaker stops calling OMDb for a while once it has failed threshold times
+// in a row, so an outage fails requests fast instead of letting every one of
+// them wait out its retries and timeouts. After cooldown one call is let
+// through as a probe: if it succeeds the breaker closes, otherwise it opens
+// for another cooldown.
+//
+// Only failures that say OMDb itself is unwell count: network errors,
+// timeouts, 429s and 5xx. Misses, an exhausted quota, our own rate limiter
+// and callers that gave up are neither failures nor successes.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int // 0 disables the breaker
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time // zero while closed
+	probing   bool
+}
+
+// omdbBreaker guards fetchJSON, set from OMDB_BREAKER_THRESHOLD and
+// OMDB_BREAKER_COOLDOWN.
+var omdbBreaker = &breaker{threshold: 5, cooldown: 30 * time.Second}
+
+func (b *breaker) stateAt(now time.Time) string {
+	switch {
+	case b.openUntil.IsZero():
+		return "closed"
+	case now.Before(b.openUntil):
+		return "open"
+	}
+	return "half_open"
+}
+
+// allow reports whether a call may go ahead, and whether it is the probe
+// of a half-open breaker.
+func (b *breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return false, nil
+	}
+	switch b.stateAt(time.Now()) {
+	case "open":
+		return false, errCircuitOpen
+	case "half_open":
+		if b.probing {
+			return false, errCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record counts the outcome of a call allow let through.
+func (b *breaker) record(ctx context.Context, probe bool, err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case err == nil:
+		b.failures, b.openUntil = 0, time.Time{}
+	case upstreamFailure(ctx, err):
+		b.failures++
+		if probe || (b.openUntil.IsZero() && b.failures >= b.threshold) {
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+	}
+}
+
+// upstreamFailure reports whether err from a fetch means OMDb is failing.
+func upstreamFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, errRateLimited) || errors.Is(err, errQuotaExceeded) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == 429 || se.code >= 500
+	}
+	return true
+}
+
+// retryAfter is how long until an open breaker lets a probe through.
+func (b *breaker) retryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(time.Until(b.openUntil), 0)
+}
+
+type breakerStatus struct {
+	State               string `json:"state" enums:"closed,open,half_open,disabled"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	OpenUntil           string `json:"open_until,omitempty"`
+}
+
+func (b *breaker) status() breakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return breakerStatus{State: "disabled"}
+	}
+	s := breakerStatus{State: b.stateAt(time.Now()), ConsecutiveFailures: b.failures}
+	if !b.openUntil.IsZero() {
+		s.OpenUntil = b.openUntil.UTC().Format(time.RFC3339)
+	}
+	return s
+}
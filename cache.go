@@ -0,0 +1,180 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// detailTTL is how long a fetched title stays cached, set from CACHE_TTL.
+// Zero disables the cache.
+var detailTTL = time.Hour
+
+// negativeTTL is how long a title OMDb says doesn't exist is remembered as
+// missing, set from NEGATIVE_CACHE_TTL. It is kept short so a title that
+// appears later is found again soon. Zero disables negative caching.
+var negativeTTL = 10 * time.Minute
+
+// detailCacheMax caps how many keys the detail cache holds, set from
+// CACHE_MAX_ENTRIES. Zero means no cap.
+var detailCacheMax = 5000
+
+type cacheEntry struct {
+	key     string
+	m       Movie
+	missing bool
+	expires time.Time
+}
+
+// detailCache holds OMDb detail lookups by "i:<imdbID>" and "t:<title>".
+// Entries are stored and returned by value so callers can't modify what
+// other requests see. A key can also be cached as missing; any later put
+// replaces that.
+//
+// Entries expire after their TTL, and once detailCacheMax keys are held a
+// put evicts the least recently used. order runs from most to least
+// recently used and get moves a hit to the front; expired entries are only
+// removed when looked up or evicted.
+type detailCache struct {
+	mu    sync.Mutex
+	m     map[string]*list.Element
+	order *list.List
+}
+
+var details = newDetailCache()
+
+func newDetailCache() *detailCache {
+	return &detailCache{m: map[string]*list.Element{}, order: list.New()}
+}
+
+func idKey(id string) string { return "i:" + id }
+
+// titleKey is the cache key for a title lookup, optionally restricted to
+// an OMDb type.
+func titleKey(title, typ string) string {
+	k := "t:" + strings.ToLower(strings.TrimSpace(title))
+	if typ != "" {
+		k = typ + ":" + k
+	}
+	return k
+}
+
+// get reports whether key is cached. A hit with a nil Movie means the key
+// is cached as missing.
+func (dc *detailCache) get(key string) (*Movie, bool) {
+	if detailTTL <= 0 && negativeTTL <= 0 {
+		return nil, false
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	el, ok := dc.m[key]
+	if ok && time.Now().After(el.Value.(*cacheEntry).expires) {
+		dc.remove(el)
+		ok = false
+	}
+	if !ok {
+		cacheLookups.WithLabelValues("miss").Inc()
+		cacheMisses.Add(1)
+		return nil, false
+	}
+	dc.order.MoveToFront(el)
+	e := el.Value.(*cacheEntry)
+	if e.missing {
+		cacheLookups.WithLabelValues("negative_hit").Inc()
+		cacheNegativeHits.Add(1)
+		return nil, true
+	}
+	cacheLookups.WithLabelValues("hit").Inc()
+	cacheHits.Add(1)
+	m := e.m
+	return &m, true
+}
+
+func (dc *detailCache) put(m *Movie, keys ...string) {
+	if detailTTL <= 0 {
+		return
+	}
+	dc.store(cacheEntry{m: *m, expires: time.Now().Add(detailTTL)}, keys)
+}
+
+func (dc *detailCache) putMissing(keys ...string) {
+	if negativeTTL <= 0 {
+		return
+	}
+	dc.store(cacheEntry{missing: true, expires: time.Now().Add(negativeTTL)}, keys)
+}
+
+// store saves e under every key, replacing what was there, and evicts from
+// the back of order while over detailCacheMax.
+func (dc *detailCache) store(e cacheEntry, keys []string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := dc.m[k]; ok {
+			dc.remove(el)
+		}
+		ke := e
+		ke.key = k
+		dc.m[k] = dc.order.PushFront(&ke)
+	}
+	for detailCacheMax > 0 && dc.order.Len() > detailCacheMax {
+		dc.remove(dc.order.Back())
+		cacheEvictions.Inc()
+	}
+}
+
+func (dc *detailCache) remove(el *list.Element) {
+	dc.order.Remove(el)
+	delete(dc.m, el.Value.(*cacheEntry).key)
+}
+
+// len is the number of keys cached, expired ones included until they are
+// next looked up or evicted.
+func (dc *detailCache) len() int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return len(dc.m)
+}
+
+// inflight joins concurrent cache misses for the same key, so a burst of
+// lookups for one title, within a request or across requests, costs one
+// OMDb fetch instead of one each.
+var inflight singleflight.Group
+
+// shared runs fetch once for every concurrent caller with key. fetch is
+// detached from the first caller's cancellation so that caller giving up
+// doesn't fail the others, but keeps its deadline, or lookupDeadline if it
+// has none, so the rate limiter can still refuse a wait too long to be worth
+// it; each caller still stops waiting when its own ctx is done, and gets its
+// own copy of the movie, as from the cache.
+func shared(ctx context.Context, key string, fetch func(context.Context) (*Movie, error)) (*Movie, error) {
+	ch := inflight.DoChan(key, func() (interface{}, error) {
+		fctx, cancel := detach(ctx)
+		defer cancel()
+		return fetch(fctx)
+	})
+	select {
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		m := *r.Val.(*Movie)
+		return &m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detach is ctx without its cancellation, bounded by ctx's deadline when it
+// has one and by lookupDeadline otherwise.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := context.WithoutCancel(ctx)
+	if dl, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(d, dl)
+	}
+	return context.WithTimeout(d, lookupDeadline)
+}
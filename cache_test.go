@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func cacheSettings(t *testing.T, ttl, neg time.Duration, maxKeys int) {
+	t.Helper()
+	oldTTL, oldNeg, oldMax := detailTTL, negativeTTL, detailCacheMax
+	detailTTL, negativeTTL, detailCacheMax = ttl, neg, maxKeys
+	t.Cleanup(func() { detailTTL, negativeTTL, detailCacheMax = oldTTL, oldNeg, oldMax })
+}
+
+func cached(dc *detailCache, key string) bool {
+	_, ok := dc.get(key)
+	return ok
+}
+
+func TestDetailCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cacheSettings(t, time.Hour, time.Hour, 3)
+	dc := newDetailCache()
+	for _, id := range []string{"a", "b", "c"} {
+		dc.put(&Movie{ImdbID: id}, idKey(id))
+	}
+	cached(dc, idKey("a")) // b is now the least recently used
+	dc.put(&Movie{ImdbID: "d"}, idKey("d"))
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if got := cached(dc, idKey(id)); got != want {
+			t.Errorf("%s cached = %v, want %v", id, got, want)
+		}
+	}
+	// Every key of a put counts, and a key cached as missing counts too.
+	dc.putMissing(idKey("e"))
+	dc.put(&Movie{ImdbID: "f"}, idKey("f"), titleKey("F", ""))
+	if n := dc.len(); n != 3 {
+		t.Errorf("len = %d, want 3", n)
+	}
+	for id, want := range map[string]bool{"a": false, "c": false, "d": false, "e": true, "f": true} {
+		if got := cached(dc, idKey(id)); got != want {
+			t.Errorf("%s cached = %v, want %v", id, got, want)
+		}
+	}
+	if !cached(dc, titleKey("f", "")) {
+		t.Error("title key of f not cached")
+	}
+}
+
+func TestDetailCacheTTLAndLRU(t *testing.T) {
+	cacheSettings(t, 20*time.Millisecond, time.Hour, 3)
+	dc := newDetailCache()
+	dc.put(&Movie{ImdbID: "old"}, idKey("old"))
+	detailTTL = time.Hour
+	dc.put(&Movie{ImdbID: "b"}, idKey("b"))
+	dc.put(&Movie{ImdbID: "c"}, idKey("c"))
+	time.Sleep(30 * time.Millisecond)
+
+	// Being used recently doesn't keep an entry past its TTL, and the
+	// expired lookup removes it, freeing its slot.
+	if cached(dc, idKey("old")) {
+		t.Fatal("expired entry still cached")
+	}
+	if n := dc.len(); n != 2 {
+		t.Fatalf("len = %d after expired lookup, want 2", n)
+	}
+	dc.put(&Movie{ImdbID: "d"}, idKey("d"))
+	for _, id := range []string{"b", "c", "d"} {
+		if !cached(dc, idKey(id)) {
+			t.Errorf("%s evicted while a slot was free", id)
+		}
+	}
+
+	// An expired entry nobody looks up still holds its slot until it is
+	// the least recently used and evicted.
+	detailTTL = 20 * time.Millisecond
+	dc.put(&Movie{ImdbID: "stale"}, idKey("stale")) // evicts b
+	time.Sleep(30 * time.Millisecond)
+	detailTTL = time.Hour
+	if n := dc.len(); n != 3 {
+		t.Fatalf("len = %d, want 3", n)
+	}
+	cached(dc, idKey("c"))
+	cached(dc, idKey("d"))
+	dc.put(&Movie{ImdbID: "e"}, idKey("e")) // evicts stale, now at the back
+	if n := dc.len(); n != 3 {
+		t.Errorf("len = %d, want 3", n)
+	}
+	for id, want := range map[string]bool{"b": false, "c": true, "d": true, "e": true, "stale": false} {
+		if got := cached(dc, idKey(id)); got != want {
+			t.Errorf("%s cached = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestDetailCacheMissingAndCopies(t *testing.T) {
+	cacheSettings(t, time.Hour, time.Hour, 0)
+	dc := newDetailCache()
+	dc.putMissing(idKey("x"))
+	if m, ok := dc.get(idKey("x")); !ok || m != nil {
+		t.Fatalf("missing entry = %v, %v", m, ok)
+	}
+	dc.put(&Movie{ImdbID: "x", Title: "X"}, idKey("x"))
+	m, ok := dc.get(idKey("x"))
+	if !ok || m == nil || m.Title != "X" {
+		t.Fatalf("put after putMissing = %v, %v", m, ok)
+	}
+	m.Title = "changed"
+	if m, _ := dc.get(idKey("x")); m.Title != "X" {
+		t.Errorf("cached movie changed to %q through a returned copy", m.Title)
+	}
+}
+
+func TestConcurrentLookupsShareOneFetch(t *testing.T) {
+	f := newFakeCatalog()
+	f.delay = 50 * time.Millisecond
+	startFakeOMDb(t, f)
+	const n = 20
+	lookups := map[string]func(context.Context) (*Movie, error){
+		"tt1375666": func(ctx context.Context) (*Movie, error) { return getDetailByID(ctx, "tt1375666") },
+		"Inception": func(ctx context.Context) (*Movie, error) { return getDetailByTitle(ctx, "Inception", "") },
+	}
+	for key, lookup := range lookups {
+		details = newDetailCache()
+		var wg sync.WaitGroup
+		movies := make([]*Movie, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m, err := lookup(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				movies[i] = m
+			}()
+		}
+		wg.Wait()
+		if c := f.callsFor(key); c != 1 {
+			t.Errorf("%d concurrent lookups of %s made %d fetches, want 1", n, key, c)
+		}
+		// Each caller gets its own copy.
+		if movies[0] != nil && movies[0] == movies[1] {
+			t.Errorf("%s: callers share one *Movie", key)
+		}
+	}
+}
+
+func TestSharedLookupKeepsRateLimit(t *testing.T) {
+	f := newFakeCatalog()
+	startFakeOMDb(t, f)
+	old := omdbLimiter
+	t.Cleanup(func() { omdbLimiter = old })
+	// One token, then one every 1000s: longer than any deadline here.
+	omdbLimiter = newTokenBucket(0.001, 1)
+	omdbLimiter.reserve()
+
+	timed, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for name, ctx := range map[string]context.Context{"with deadline": timed, "without deadline": context.Background()} {
+		details = newDetailCache()
+		start := time.Now()
+		if _, err := getDetailByID(ctx, "tt1375666"); !errors.Is(err, errRateLimited) {
+			t.Errorf("%s: err = %v, want errRateLimited", name, err)
+		}
+		if d := time.Since(start); d > 100*time.Millisecond {
+			t.Errorf("%s: took %v to be refused", name, d)
+		}
+	}
+	if c := f.callsFor("tt1375666"); c != 0 {
+		t.Errorf("%d fetches reached OMDb, want 0", c)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compareSide is one of the two movies in a comparison. When the query
+// isn't found only query and not_found are set.
+type compareSide struct {
+	Query    string `json:"query"`
+	NotFound bool   `json:"not_found"`
+	*compareMovie
+}
+
+type compareMovie struct {
+	movieSummary
+	Director       string  `json:"Director"`
+	Actors         string  `json:"Actors"`
+	Runtime        *string `json:"Runtime"`
+	RuntimeMinutes *int    `json:"RuntimeMinutes"`
+	RottenTomatoes *int    `json:"RottenTomatoes"`
+	Metacritic     *int    `json:"Metacritic"`
+	BoxOffice      *string `json:"BoxOffice"`
+	BoxOfficeUSD   *int64  `json:"BoxOfficeUSD"`
+}
+
+// compareWinners names the side with the higher value of each metric: "a",
+// "b" or "tie", and empty when either side lacks it.
+type compareWinners struct {
+	Rating         string `json:"imdbRating,omitempty" enums:"a,b,tie"`
+	RottenTomatoes string `json:"RottenTomatoes,omitempty" enums:"a,b,tie"`
+	Metacritic     string `json:"Metacritic,omitempty" enums:"a,b,tie"`
+	BoxOffice      string `json:"BoxOffice,omitempty" enums:"a,b,tie"`
+}
+
+// compareShared is what the two movies have in common.
+type compareShared struct {
+	Genres    []string `json:"genres"`
+	Directors []string `json:"directors"`
+	Actors    []string `json:"actors"`
+}
+
+// compareResponse is /api/compare. A delta is a minus b, and null when
+// either side lacks the value; winners and shared need both sides found.
+type compareResponse struct {
+	A            compareSide    `json:"a"`
+	B            compareSide    `json:"b"`
+	RatingDelta  *float64       `json:"ratingDelta" example:"0.4"`
+	RuntimeDelta *int           `json:"runtimeDelta" example:"-12"`
+	YearDelta    *int           `json:"yearDelta" example:"4"`
+	Winners      compareWinners `json:"winners"`
+	Shared       *compareShared `json:"shared"`
+}
+
+// lookupMovie resolves q as an imdbID when it looks like one and as a
+// title otherwise.
+func lookupMovie(ctx context.Context, q string) (*Movie, error) {
+	if imdbIDPattern.MatchString(q) {
+		return getDetailByID(ctx, q)
+	}
+	return getDetailByTitle(ctx, q, "")
+}
+
+func compareSideOf(q string, m *Movie) compareSide {
+	if m == nil {
+		return compareSide{Query: q, NotFound: true}
+	}
+	cm := &compareMovie{
+		movieSummary:   summarize(m),
+		Director:       m.Director,
+		Actors:         m.Actors,
+		Runtime:        nullableString(m.Runtime),
+		RuntimeMinutes: nullableInt(parseRuntime(m.Runtime)),
+		BoxOffice:      nullableString(m.BoxOffice),
+	}
+	cm.RottenTomatoes, cm.Metacritic = ratingScores(m.Ratings)
+	if cm.Metacritic == nil {
+		cm.Metacritic = parseScore(m.Metascore+"/100", "/100")
+	}
+	if n, ok := parseBoxOffice(m.BoxOffice); ok {
+		cm.BoxOfficeUSD = &n
+	}
+	return compareSide{Query: q, compareMovie: cm}
+}
+
+// winner compares a and b, where ok says both are known.
+func winner(a, b float64, ok bool) string {
+	switch {
+	case !ok:
+		return ""
+	case a > b:
+		return "a"
+	case b > a:
+		return "b"
+	}
+	return "tie"
+}
+
+func intWinner(a, b *int) string {
+	if a == nil || b == nil {
+		return ""
+	}
+	return winner(float64(*a), float64(*b), true)
+}
+
+// sharedCredits is the entries of comma separated list a that b also has,
+// compared case-insensitively, in a's order.
+func sharedCredits(a, b string) []string {
+	have := map[string]bool{}
+	for _, v := range creditList(b) {
+		have[strings.ToLower(v)] = true
+	}
+	out := []string{}
+	for _, v := range creditList(a) {
+		if have[strings.ToLower(v)] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// compareHandler is GET /api/compare?a=...&b=..., two movies side by side.
+// Both are looked up at once. A side that isn't found is flagged not_found;
+// only when neither is found is the answer a 404.
+//
+//	@Summary	Compare two movies
+//	@Tags		movies
+//	@Produce	json,xml
+//	@Param		a	query		string	true	"Title or imdbID"
+//	@Param		b	query		string	true	"Title or imdbID"
+//	@Success	200	{object}	compareResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND, when neither is found"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router		/api/compare [get]
+func compareHandler(c *gin.Context) {
+	if !requireParams(c, "a", "b") {
+		return
+	}
+	queries := []string{c.Query("a"), c.Query("b")}
+	movies := make([]*Movie, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			movies[i], errs[i] = lookupMovie(c.Request.Context(), queries[i])
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, errNotFound) {
+			respondFetchError(c, err, "movie not found")
+			return
+		}
+	}
+	a, b := movies[0], movies[1]
+	if a == nil && b == nil {
+		respondErrorDetails(c, 404, codeNotFound, "movies a and b not found", gin.H{"missing": []string{"a", "b"}})
+		return
+	}
+	resp := compareResponse{A: compareSideOf(queries[0], a), B: compareSideOf(queries[1], b)}
+	if a == nil || b == nil {
+		respond(c, 200, resp)
+		return
+	}
+	if hasRating(a) && hasRating(b) {
+		d := math.Round((ratingVal(a)-ratingVal(b))*10) / 10
+		resp.RatingDelta = &d
+	}
+	if ra, rb := parseRuntime(a.Runtime), parseRuntime(b.Runtime); ra > 0 && rb > 0 {
+		d := ra - rb
+		resp.RuntimeDelta = &d
+	}
+	if ya, yb := movieYear(a), movieYear(b); ya > 0 && yb > 0 {
+		d := ya - yb
+		resp.YearDelta = &d
+	}
+	sa, sb := resp.A.compareMovie, resp.B.compareMovie
+	resp.Winners = compareWinners{
+		Rating:         winner(ratingVal(a), ratingVal(b), hasRating(a) && hasRating(b)),
+		RottenTomatoes: intWinner(sa.RottenTomatoes, sb.RottenTomatoes),
+		Metacritic:     intWinner(sa.Metacritic, sb.Metacritic),
+	}
+	if sa.BoxOfficeUSD != nil && sb.BoxOfficeUSD != nil {
+		resp.Winners.BoxOffice = winner(float64(*sa.BoxOfficeUSD), float64(*sb.BoxOfficeUSD), true)
+	}
+	resp.Shared = &compareShared{
+		Genres:    sharedCredits(a.Genre, b.Genre),
+		Directors: sharedCredits(a.Director, b.Director),
+		Actors:    sharedCredits(a.Actors, b.Actors),
+	}
+	respond(c, 200, resp)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Genre discovery has no direct OMDb endpoint, so we search seed keywords
+// and keep the results whose Genre matches. Each keyword page costs one
+// search plus up to ten detail lookups, so a crawl can spend up to
+// keywords * seedPages * 11 requests. More keywords or pages find more of the
+// catalog at a direct cost to the quota; callers can bound a single crawl
+// with crawlOpts.budget.
+//
+// A crawl searches the genre name, then that genre's own seeds, then the
+// general seedKeywords. Genre seeds are words common in titles of that genre,
+// so they surface matches far sooner than generic words, whose hits are
+// dominated by whatever is most popular overall.
+var seedKeywords = []string{
+	"the", "a", "man", "love", "night", "life", "world", "day", "girl", "war",
+	"city", "last", "house", "story", "black", "star", "dark", "king", "time",
+}
+
+// genreSeeds is keyed by lower-case genre name as OMDb spells it.
+var genreSeeds = map[string][]string{
+	"action":      {"mission", "fast", "die", "force", "strike", "kill"},
+	"adventure":   {"quest", "journey", "island", "treasure", "lost", "legend"},
+	"animation":   {"toy", "dragon", "frozen", "cars", "kung fu", "spirited"},
+	"biography":   {"story", "life", "theory", "social", "king"},
+	"comedy":      {"wedding", "party", "crazy", "funny", "meet", "bad"},
+	"crime":       {"godfather", "heist", "gangster", "murder", "heat", "city"},
+	"documentary": {"planet", "inside", "story of", "making", "march"},
+	"drama":       {"life", "story", "road", "river", "letter", "son"},
+	"family":      {"home", "dog", "christmas", "little", "family"},
+	"fantasy":     {"lord", "wizard", "magic", "dragon", "kingdom", "harry potter"},
+	"history":     {"empire", "king", "battle", "queen", "war"},
+	"horror":      {"dead", "evil", "night", "haunting", "blood", "conjuring"},
+	"music":       {"song", "rock", "band", "star", "whiplash"},
+	"musical":     {"sing", "dance", "music", "greatest showman"},
+	"mystery":     {"murder", "secret", "gone", "knives", "prestige"},
+	"romance":     {"love", "wedding", "kiss", "notebook", "before"},
+	"sci-fi":      {"star", "alien", "planet", "space", "future", "robot"},
+	"sport":       {"rocky", "ball", "champion", "race", "team"},
+	"thriller":    {"silence", "fear", "shutter", "game", "prisoners"},
+	"war":         {"war", "soldier", "saving", "battle", "dunkirk"},
+	"western":     {"west", "django", "outlaw", "gun", "unforgiven"},
+}
+var seedPages = 2
+
+// seedConfig is the JSON form of GENRE_SEED_FILE.
+type seedConfig struct {
+	Keywords []string            `json:"keywords"`
+	Genres   map[string][]string `json:"genres"`
+	Pages    int                 `json:"pages"`
+}
+
+// loadSeedKeywords reads GENRE_SEED_FILE or else GENRE_SEED_KEYWORDS, and
+// GENRE_SEED_PAGES. A .json file may set keywords, per-genre seeds (which
+// replace the built-in list for those genres) and pages; any other file is
+// one keyword per line or comma separated, with # for comments.
+func loadSeedKeywords() {
+	src := os.Getenv("GENRE_SEED_KEYWORDS")
+	if path := os.Getenv("GENRE_SEED_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			log.Printf("GENRE_SEED_FILE: %v, using defaults", err)
+		case strings.HasSuffix(strings.ToLower(path), ".json"):
+			var cfg seedConfig
+			if err := json.Unmarshal(b, &cfg); err != nil {
+				log.Printf("GENRE_SEED_FILE: %v, using defaults", err)
+				break
+			}
+			src = strings.Join(cfg.Keywords, ",")
+			for g, kw := range cfg.Genres {
+				genreSeeds[strings.ToLower(g)] = splitKeywords(strings.Join(kw, ","))
+			}
+			if cfg.Pages > 0 {
+				seedPages = cfg.Pages
+			}
+		default:
+			src = stripComments(string(b))
+		}
+	}
+	if kw := splitKeywords(src); len(kw) > 0 {
+		seedKeywords = kw
+	}
+	if v, err := strconv.Atoi(os.Getenv("GENRE_SEED_PAGES")); err == nil && v > 0 {
+		seedPages = v
+	}
+}
+
+// crawlKeywords is the search order for gen, without repeats: each of its
+// comma separated genres and their seeds, then the general seeds.
+func crawlKeywords(gen string) []string {
+	var kw []string
+	for _, g := range splitList(gen) {
+		kw = append(kw, g)
+		kw = append(kw, genreSeeds[g]...)
+	}
+	kw = append(kw, seedKeywords...)
+	seen := map[string]bool{}
+	out := kw[:0]
+	for _, k := range kw {
+		if k = strings.ToLower(k); !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func stripComments(v string) string {
+	lines := strings.Split(v, "\n")
+	for i, l := range lines {
+		if j := strings.Index(l, "#"); j >= 0 {
+			lines[i] = l[:j]
+		}
+	}
+	return strings.Join(lines, ",")
+}
+
+func splitKeywords(v string) []string {
+	out := []string{}
+	for _, k := range strings.Split(v, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// collectStats is the funnel of one genre crawl, reported with debug=true.
+type collectStats struct {
+	Searches       int `json:"keyword_searches"`
+	UniqueIDs      int `json:"unique_ids"`
+	DetailsFetched int `json:"details_fetched"`
+	GenreMatches   int `json:"genre_matches"`
+	Filtered       int `json:"dropped_by_filters"`
+
+	stopped error // errQuotaExceeded or errCircuitOpen if the crawl gave up
+}
+
+func (s *collectStats) requests() int { return s.Searches + s.UniqueIDs }
+
+// kept is how many matches survived the filters.
+func (s *collectStats) kept() int { return s.GenreMatches - s.Filtered }
+
+// crawlOpts bounds one genre crawl.
+type crawlOpts struct {
+	limit  int           // stop after this many matches
+	keep   movieFilter   // extra filter on matches, may be nil
+	rank   ranking       // order for collectTopByGenre, nil for rating
+	typ    string        // OMDb type= for the searches, "" for any
+	year   string        // OMDb y= for the searches, "" for any
+	all    bool          // a comma separated gen must match every genre, not any
+	budget int           // max OMDb requests, 0 for no cap
+	stats  *collectStats // may be nil
+}
+
+// walkGenre searches the keywords from crawlKeywords and calls visit for
+// every distinct movie whose Genre matches gen and passes opts.keep. gen may
+// list several genres separated by commas; a movie matches if its Genre
+// contains any of them, or every one with opts.all.
+func walkGenre(ctx context.Context, gen string, opts crawlOpts, visit func(*Movie)) {
+	walk(ctx, crawlKeywords(gen), genreMatcher(splitList(gen), opts.all), opts, visit)
+}
+
+func genreMatcher(genres []string, all bool) movieFilter {
+	return func(m *Movie) bool {
+		have := strings.ToLower(m.Genre)
+		for _, g := range genres {
+			if strings.Contains(have, g) {
+				if !all {
+					return true
+				}
+			} else if all {
+				return false
+			}
+		}
+		return all || len(genres) == 0
+	}
+}
+
+// walk searches each keyword up to seedPages deep and calls visit for every
+// distinct movie that satisfies match and opts.keep.
+func walk(ctx context.Context, kw []string, match movieFilter, opts crawlOpts, visit func(*Movie)) {
+	stats := opts.stats
+	if stats == nil {
+		stats = &collectStats{}
+	}
+	overBudget := func() bool { return opts.budget > 0 && stats.requests() >= opts.budget }
+	seen := map[string]bool{}
+	matched := 0
+	for _, k := range kw {
+		for p := 1; p <= seedPages; p++ {
+			if ctx.Err() != nil || overBudget() {
+				return
+			}
+			stats.Searches++
+			sr, err := searchPage(ctx, k, p, opts.typ, opts.year)
+			if isQuotaError(sr.Error) {
+				err = errQuotaExceeded
+			}
+			if errors.Is(err, errQuotaExceeded) || errors.Is(err, errCircuitOpen) {
+				stats.stopped = err
+				return
+			}
+			var items []searchItem
+			if err == nil && sr.ok() {
+				items = sr.Search
+			}
+			for _, it := range items {
+				if ctx.Err() != nil || overBudget() {
+					return
+				}
+				if it.ImdbID == "" || seen[it.ImdbID] {
+					continue
+				}
+				seen[it.ImdbID] = true
+				stats.UniqueIDs++
+				md, err := getDetailByID(ctx, it.ImdbID)
+				if errors.Is(err, errQuotaExceeded) || errors.Is(err, errCircuitOpen) {
+					stats.stopped = err
+					return
+				}
+				if err != nil {
+					continue
+				}
+				stats.DetailsFetched++
+				if !match(md) {
+					continue
+				}
+				stats.GenreMatches++
+				if opts.keep != nil && !opts.keep(md) {
+					stats.Filtered++
+					continue
+				}
+				visit(md)
+				if matched++; matched >= opts.limit {
+					return
+				}
+			}
+			if lastPage(len(items)) {
+				break
+			}
+		}
+	}
+}
+
+// collectByGenre returns up to opts.limit matches for gen in crawl order.
+// An empty gen matches every title the seed keywords turn up.
+func collectByGenre(ctx context.Context, gen string, opts crawlOpts) []*Movie {
+	out := []*Movie{}
+	walkGenre(ctx, gen, opts, func(m *Movie) { out = append(out, m) })
+	return out
+}
+
+// collectTopByGenre is collectByGenre followed by sorting on opts.rank, but
+// only ever holds the n best candidates instead of the whole pool.
+func collectTopByGenre(ctx context.Context, gen string, n int, opts crawlOpts) []*Movie {
+	t := newTopN(n, opts.rank)
+	walkGenre(ctx, gen, opts, t.offer)
+	return t.sorted()
+}
+
+// collectTopByPerson searches OMDb for name and keeps the n best movies whose
+// field (Director or Actors) lists that person. OMDb has no people search, so
+// this only finds titles where the name shows up in the search index, but
+// unlike a genre crawl every hit is a real credit.
+func collectTopByPerson(ctx context.Context, name string, field func(*Movie) string, n int, opts crawlOpts) []*Movie {
+	t := newTopN(n, opts.rank)
+	walk(ctx, []string{name}, func(m *Movie) bool { return hasPerson(field(m), name) }, opts, t.offer)
+	return t.sorted()
+}
+
+// hasPerson reports whether the comma separated credit list names person,
+// as a whole entry, ignoring case and runs of spaces.
+func hasPerson(list, person string) bool {
+	person = strings.Join(strings.Fields(person), " ")
+	for _, p := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.Join(strings.Fields(p), " "), person) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,2889 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/api/actor/filmography": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "An actor's movies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Actor, e.g. Tom Hanks",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 15,
+                        "description": "Movies to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to a type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.filmographyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/warm": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": [],
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Only registered when ADMIN_TOKEN is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Warm the detail cache in the background",
+                "parameters": [
+                    {
+                        "description": "Titles and genres to fetch, at most 50 entries",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.warmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/main.warmResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "UNAUTHORIZED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/compare": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Compare two movies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Title or imdbID",
+                        "name": "a",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title or imdbID",
+                        "name": "b",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.compareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND, when neither is found",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/episode": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Look up one episode of a series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Series title",
+                        "name": "series_title",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Season number",
+                        "name": "season",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Episode number",
+                        "name": "episode_number",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "short",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "Plot length, DEFAULT_PLOT if unset",
+                        "name": "plot",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to keep",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.episodeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/health": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "OMDb reachability and key check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.healthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.healthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/movie": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Look up a movie by title or imdbID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "imdbID; wins over title",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Release year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "short",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "Plot length, DEFAULT_PLOT if unset",
+                        "name": "plot",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return Ratings on a 0-100 scale",
+                        "name": "normalize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to keep",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.movieLookupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/movie/id/{imdbID}": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Look up a movie by imdbID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "imdbID, e.g. tt1375666",
+                        "name": "imdbID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "short",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "Plot length, DEFAULT_PLOT if unset",
+                        "name": "plot",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return Ratings on a 0-100 scale",
+                        "name": "normalize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to keep",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.movieResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/movies/batch": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Also served at POST /api/movies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Look up many movies at once",
+                "parameters": [
+                    {
+                        "description": "Exactly one of titles or ids, at most 50 entries",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.batchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.batchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/movies/genre": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "OMDb has no genre search, so this crawls seed keywords; see crawl.go.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Top movies of a genre",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre, or comma separated genres, e.g. action,sci-fi",
+                        "name": "genre",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Whether a movie needs all the genres or any",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 15,
+                        "description": "Movies to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Movies to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page of page_size movies, from 1; instead of offset",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Same as limit",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rating",
+                            "year",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "last",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Where unrated movies sort",
+                        "name": "missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only movies with poster, plot, rating and genre",
+                        "name": "complete_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earliest year",
+                        "name": "year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest year",
+                        "name": "year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest imdbRating, 0-10",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Highest imdbRating, 0-10",
+                        "name": "max_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Same as min_rating",
+                        "name": "rating_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Same as max_rating",
+                        "name": "rating_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cap on OMDb requests for the crawl",
+                        "name": "max_requests",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to a type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include crawl diagnostics",
+                        "name": "debug",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.genreResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/movies/year": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Top movies of a year",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Release year, e.g. 2019",
+                        "name": "year",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre, e.g. Drama",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 15,
+                        "description": "Movies to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rating",
+                            "year",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to a type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.yearResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/poster/{imdbID}": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Also served at GET /api/poster?imdbID=...",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Poster image for a title",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "imdbID",
+                        "name": "imdbID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/random": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "A random movie, optionally from a genre",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre, e.g. Comedy",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest imdbRating",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to a type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return Ratings on a 0-100 scale",
+                        "name": "normalize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.randomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND, with the genre and filters in details",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/recommend": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Recommend movies like a favorite",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Favorite movie title (or favorite_movie)",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Favorite movie imdbID",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated imdbIDs or titles to leave out",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "last",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Where unrated movies sort",
+                        "name": "missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only movies with poster, plot, rating and genre",
+                        "name": "complete_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest imdbRating",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earliest year",
+                        "name": "year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest year",
+                        "name": "year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated genres, any of which must match",
+                        "name": "genres",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated genres to leave out",
+                        "name": "exclude_genres",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated imdbIDs to leave out",
+                        "name": "exclude_ids",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict the favorite and recommendations to a type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.recommendResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search titles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page, from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pages to read from page on, at most 5",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "movie",
+                            "series",
+                            "episode"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to a type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rating",
+                            "year",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort key; relevance order if unset",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.searchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/season": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "List the episodes of a season",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Series title",
+                        "name": "series_title",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Season number",
+                        "name": "season",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.seasonResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/series": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Without season the response is a seriesResponse; with it, a seasonResponse.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Series metadata, or one season's episodes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Series title",
+                        "name": "title",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Season number",
+                        "name": "season",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.seriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "RATE_LIMITED, QUOTA_EXCEEDED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "UPSTREAM_ERROR, while the OMDb circuit breaker is open",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stats": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "OMDb usage and cache counters since start",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.statsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/watchlist": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "List the watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist owner",
+                        "name": "X-User-ID",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.watchlistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Add a title to the watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist owner",
+                        "name": "X-User-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Title to add",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.watchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "already saved",
+                        "schema": {
+                            "$ref": "#/definitions/main.watchAddResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "added",
+                        "schema": {
+                            "$ref": "#/definitions/main.watchAddResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM, INVALID_PARAM, INVALID_BODY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/watchlist/{imdbID}": {
+            "delete": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Remove a title from the watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist owner",
+                        "name": "X-User-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "imdbID",
+                        "name": "imdbID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "MISSING_PARAM",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.statusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.statusResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.statusResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.apiError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "enum": [
+                        "MISSING_PARAM",
+                        "INVALID_PARAM",
+                        "INVALID_BODY",
+                        "NOT_FOUND",
+                        "UPSTREAM_ERROR",
+                        "RATE_LIMITED",
+                        "QUOTA_EXCEEDED",
+                        "TIMEOUT",
+                        "UNAUTHORIZED",
+                        "UNSUPPORTED_FORMAT",
+                        "INTERNAL_ERROR"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/main.errCode"
+                        }
+                    ]
+                },
+                "details": {
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "movie not found"
+                }
+            }
+        },
+        "main.batchItem": {
+            "type": "object",
+            "properties": {
+                "Director": {
+                    "type": "string"
+                },
+                "Genre": {
+                    "type": "string"
+                },
+                "Metacritic": {
+                    "type": "integer",
+                    "example": 74
+                },
+                "RottenTomatoes": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/main.apiError"
+                },
+                "found": {
+                    "type": "boolean"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.batchRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "titles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.batchResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.batchItem"
+                    }
+                }
+            }
+        },
+        "main.breakerStatus": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "open_until": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "closed",
+                        "open",
+                        "half_open",
+                        "disabled"
+                    ]
+                }
+            }
+        },
+        "main.cacheStats": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "negative_hits": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.collectStats": {
+            "type": "object",
+            "properties": {
+                "details_fetched": {
+                    "type": "integer"
+                },
+                "dropped_by_filters": {
+                    "type": "integer"
+                },
+                "genre_matches": {
+                    "type": "integer"
+                },
+                "keyword_searches": {
+                    "type": "integer"
+                },
+                "unique_ids": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.compareResponse": {
+            "type": "object",
+            "properties": {
+                "a": {
+                    "$ref": "#/definitions/main.compareSide"
+                },
+                "b": {
+                    "$ref": "#/definitions/main.compareSide"
+                },
+                "ratingDelta": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "runtimeDelta": {
+                    "type": "integer",
+                    "example": -12
+                },
+                "shared": {
+                    "$ref": "#/definitions/main.compareShared"
+                },
+                "winners": {
+                    "$ref": "#/definitions/main.compareWinners"
+                },
+                "yearDelta": {
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "main.compareShared": {
+            "type": "object",
+            "properties": {
+                "actors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "directors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.compareSide": {
+            "type": "object",
+            "properties": {
+                "Actors": {
+                    "type": "string"
+                },
+                "BoxOffice": {
+                    "type": "string"
+                },
+                "BoxOfficeUSD": {
+                    "type": "integer"
+                },
+                "Director": {
+                    "type": "string"
+                },
+                "Genre": {
+                    "type": "string"
+                },
+                "Metacritic": {
+                    "type": "integer"
+                },
+                "RottenTomatoes": {
+                    "type": "integer"
+                },
+                "Runtime": {
+                    "type": "string"
+                },
+                "RuntimeMinutes": {
+                    "type": "integer"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "not_found": {
+                    "type": "boolean"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.compareWinners": {
+            "type": "object",
+            "properties": {
+                "BoxOffice": {
+                    "type": "string",
+                    "enum": [
+                        "a",
+                        "b",
+                        "tie"
+                    ]
+                },
+                "Metacritic": {
+                    "type": "string",
+                    "enum": [
+                        "a",
+                        "b",
+                        "tie"
+                    ]
+                },
+                "RottenTomatoes": {
+                    "type": "string",
+                    "enum": [
+                        "a",
+                        "b",
+                        "tie"
+                    ]
+                },
+                "imdbRating": {
+                    "type": "string",
+                    "enum": [
+                        "a",
+                        "b",
+                        "tie"
+                    ]
+                }
+            }
+        },
+        "main.episodeResponse": {
+            "type": "object",
+            "properties": {
+                "Episode": {
+                    "type": "string"
+                },
+                "Plot": {
+                    "type": "string"
+                },
+                "Poster": {
+                    "type": "string"
+                },
+                "Released": {
+                    "type": "string"
+                },
+                "Season": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.errCode": {
+            "type": "string",
+            "enum": [
+                "MISSING_PARAM",
+                "INVALID_PARAM",
+                "INVALID_BODY",
+                "NOT_FOUND",
+                "UPSTREAM_ERROR",
+                "RATE_LIMITED",
+                "QUOTA_EXCEEDED",
+                "TIMEOUT",
+                "UNAUTHORIZED",
+                "UNSUPPORTED_FORMAT",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "codeMissingParam",
+                "codeInvalidParam",
+                "codeInvalidBody",
+                "codeNotFound",
+                "codeUpstream",
+                "codeRateLimited",
+                "codeQuotaExceeded",
+                "codeTimeout",
+                "codeUnauthorized",
+                "codeUnsupportedFormat",
+                "codeInternal"
+            ]
+        },
+        "main.errorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/main.apiError"
+                }
+            }
+        },
+        "main.filmographyResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "movies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.movieSummary"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Tom Hanks"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.genreResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "diagnostics": {
+                    "$ref": "#/definitions/main.collectStats"
+                },
+                "filters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "action",
+                        "sci-fi"
+                    ]
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "any",
+                        "all"
+                    ]
+                },
+                "movies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.movieSummary"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "sort": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.healthResponse": {
+            "type": "object",
+            "properties": {
+                "breaker": {
+                    "$ref": "#/definitions/main.breakerStatus"
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "omdb_latency_ms": {
+                    "type": "integer"
+                },
+                "omdb_rate_tokens": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "unavailable"
+                    ]
+                }
+            }
+        },
+        "main.movieLookupResponse": {
+            "type": "object",
+            "properties": {
+                "Awards": {
+                    "type": "string"
+                },
+                "BoxOffice": {
+                    "type": "string",
+                    "example": "$292,587,330"
+                },
+                "BoxOfficeUSD": {
+                    "type": "integer",
+                    "example": 292587330
+                },
+                "Country": {
+                    "type": "string"
+                },
+                "Director": {
+                    "type": "string"
+                },
+                "Language": {
+                    "type": "string",
+                    "example": "English, Japanese, French"
+                },
+                "Metacritic": {
+                    "type": "integer",
+                    "example": 74
+                },
+                "Plot": {
+                    "type": "string"
+                },
+                "Poster": {
+                    "type": "string"
+                },
+                "Production": {
+                    "type": "string"
+                },
+                "Rated": {
+                    "type": "string",
+                    "example": "PG-13"
+                },
+                "Ratings": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "Released": {
+                    "type": "string",
+                    "example": "16 Jul 2010"
+                },
+                "RottenTomatoes": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "Runtime": {
+                    "type": "string",
+                    "example": "148 min"
+                },
+                "RuntimeMinutes": {
+                    "type": "integer",
+                    "example": 148
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Inception"
+                },
+                "Writer": {
+                    "type": "string",
+                    "example": "Christopher Nolan"
+                },
+                "Year": {
+                    "type": "string",
+                    "example": "2010"
+                },
+                "didYouMean": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.titleRef"
+                    }
+                },
+                "matchedExactly": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.movieResponse": {
+            "type": "object",
+            "properties": {
+                "Awards": {
+                    "type": "string"
+                },
+                "BoxOffice": {
+                    "type": "string",
+                    "example": "$292,587,330"
+                },
+                "BoxOfficeUSD": {
+                    "type": "integer",
+                    "example": 292587330
+                },
+                "Country": {
+                    "type": "string"
+                },
+                "Director": {
+                    "type": "string"
+                },
+                "Language": {
+                    "type": "string",
+                    "example": "English, Japanese, French"
+                },
+                "Metacritic": {
+                    "type": "integer",
+                    "example": 74
+                },
+                "Plot": {
+                    "type": "string"
+                },
+                "Poster": {
+                    "type": "string"
+                },
+                "Production": {
+                    "type": "string"
+                },
+                "Rated": {
+                    "type": "string",
+                    "example": "PG-13"
+                },
+                "Ratings": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "Released": {
+                    "type": "string",
+                    "example": "16 Jul 2010"
+                },
+                "RottenTomatoes": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "Runtime": {
+                    "type": "string",
+                    "example": "148 min"
+                },
+                "RuntimeMinutes": {
+                    "type": "integer",
+                    "example": 148
+                },
+                "Title": {
+                    "type": "string",
+                    "example": "Inception"
+                },
+                "Writer": {
+                    "type": "string",
+                    "example": "Christopher Nolan"
+                },
+                "Year": {
+                    "type": "string",
+                    "example": "2010"
+                }
+            }
+        },
+        "main.movieSummary": {
+            "type": "object",
+            "properties": {
+                "Genre": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.omdbStats": {
+            "type": "object",
+            "properties": {
+                "avg_latency_ms": {
+                    "type": "number"
+                },
+                "by_type": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "calls": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.randomResponse": {
+            "type": "object",
+            "properties": {
+                "filters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "movie": {
+                    "$ref": "#/definitions/main.movieResponse"
+                },
+                "pool_size": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.reasonCode": {
+            "type": "string",
+            "enum": [
+                "GENRE_MATCH",
+                "SAME_DIRECTOR",
+                "SHARED_ACTOR",
+                "POPULAR_FALLBACK"
+            ],
+            "x-enum-varnames": [
+                "reasonGenreMatch",
+                "reasonSameDirector",
+                "reasonSharedActor",
+                "reasonPopularFallback"
+            ]
+        },
+        "main.recommendItem": {
+            "type": "object",
+            "properties": {
+                "Actors": {
+                    "type": "string"
+                },
+                "Director": {
+                    "type": "string"
+                },
+                "Genre": {
+                    "type": "string"
+                },
+                "Metacritic": {
+                    "type": "integer",
+                    "example": 74
+                },
+                "Poster": {
+                    "type": "string"
+                },
+                "RottenTomatoes": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "matched": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Directed by Christopher Nolan"
+                },
+                "reasonCode": {
+                    "enum": [
+                        "GENRE_MATCH",
+                        "SAME_DIRECTOR",
+                        "SHARED_ACTOR",
+                        "POPULAR_FALLBACK"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/main.reasonCode"
+                        }
+                    ]
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.recommendResponse": {
+            "type": "object",
+            "properties": {
+                "favorite_movie": {
+                    "type": "string"
+                },
+                "recommendations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.recommendItem"
+                    }
+                },
+                "seed_match": {
+                    "$ref": "#/definitions/main.seedMatch"
+                }
+            }
+        },
+        "main.searchItem": {
+            "type": "object",
+            "properties": {
+                "Title": {
+                    "type": "string"
+                },
+                "Type": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.searchResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.searchItem"
+                    }
+                },
+                "sort": {
+                    "type": "string"
+                },
+                "totalPages": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.seasonEpisode": {
+            "type": "object",
+            "properties": {
+                "Episode": {
+                    "type": "string"
+                },
+                "Released": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.seasonResponse": {
+            "type": "object",
+            "properties": {
+                "Season": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "episodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.seasonEpisode"
+                    }
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.seedMatch": {
+            "type": "object",
+            "properties": {
+                "Title": {
+                    "type": "string",
+                    "example": "Inception"
+                },
+                "imdbID": {
+                    "type": "string",
+                    "example": "tt1375666"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "inceptoin"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.78
+                }
+            }
+        },
+        "main.seriesResponse": {
+            "type": "object",
+            "properties": {
+                "Genre": {
+                    "type": "string"
+                },
+                "Plot": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string",
+                    "example": "2008–2013"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "main.statsResponse": {
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/main.cacheStats"
+                },
+                "omdb": {
+                    "$ref": "#/definitions/main.omdbStats"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.statusResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "unavailable"
+                    ]
+                }
+            }
+        },
+        "main.titleRef": {
+            "type": "object",
+            "properties": {
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.warmRequest": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "titles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.warmResponse": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "integer"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "queued"
+                },
+                "titles": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.watchAddResponse": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "boolean"
+                },
+                "item": {
+                    "$ref": "#/definitions/main.watchItem"
+                }
+            }
+        },
+        "main.watchEntry": {
+            "type": "object",
+            "properties": {
+                "Director": {
+                    "type": "string"
+                },
+                "Genre": {
+                    "type": "string"
+                },
+                "Poster": {
+                    "type": "string"
+                },
+                "Runtime": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "addedAt": {
+                    "type": "string"
+                },
+                "hydrated": {
+                    "type": "boolean"
+                },
+                "imdbID": {
+                    "type": "string"
+                },
+                "imdbRating": {
+                    "type": "string"
+                },
+                "totalSeasons": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.watchItem": {
+            "type": "object",
+            "properties": {
+                "Poster": {
+                    "type": "string"
+                },
+                "Title": {
+                    "type": "string"
+                },
+                "Year": {
+                    "type": "string"
+                },
+                "addedAt": {
+                    "type": "string"
+                },
+                "imdbID": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.watchRequest": {
+            "type": "object",
+            "properties": {
+                "imdbID": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.watchlistResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.watchEntry"
+                    }
+                }
+            }
+        },
+        "main.yearResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "movies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.movieSummary"
+                    }
+                },
+                "order": {
+                    "type": "string"
+                },
+                "sort": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer",
+                    "example": 2019
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "APIKey": {
+            "description": "One of SERVICE_API_KEYS, required on /api/* when that is set.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "AdminToken": {
+            "description": "\"Bearer \" followed by ADMIN_TOKEN.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0",
+	Host:             "",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "Postman backend API",
+	Description:      "Movie, series and recommendation lookups backed by OMDb. Errors share one envelope: {\"error\": {\"code\", \"message\", \"details\"}}. Send Accept: application/xml or format=xml for XML with the same element names.",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagWriter holds a GET response until the handler is done so etags can
+// hash it. Like gzipWriter it only buffers; nothing here streams.
+type etagWriter struct {
+	gin.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *etagWriter) WriteHeader(code int) { w.status = code }
+
+func (w *etagWriter) WriteHeaderNow() {}
+
+func (w *etagWriter) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) { return w.buf.Write(b) }
+
+func (w *etagWriter) WriteString(s string) (int, error) { return w.buf.WriteString(s) }
+
+func (w *etagWriter) Flush() {}
+
+// etagMatches reports whether an If-None-Match value names tag. The
+// comparison is weak, as RFC 9110 asks for If-None-Match.
+func etagMatches(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etags sets an ETag, a hash of the body, on successful GET responses and
+// answers 304 Not Modified when If-None-Match already names it. The tag is
+// weak because gzipResponses, further out, may encode the same body
+// differently.
+func etags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		w := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		status := w.Status()
+		if status == 200 {
+			sum := sha256.Sum256(w.buf.Bytes())
+			tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			c.Header("ETag", tag)
+			if etagMatches(c.GetHeader("If-None-Match"), tag) {
+				h := c.Writer.Header()
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				c.Writer.WriteHeader(304)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		c.Writer.WriteHeader(status)
+		c.Writer.Write(w.buf.Bytes())
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOMDb answers the parts of the OMDb API the handlers use from a fixed
+// catalog: i= and t= lookups, t= with Season and Episode, and paged s=
+// searches that match on a title substring, ten to a page.
+type fakeOMDb struct {
+	movies   []Movie // in search order
+	episodes map[string]Movie
+
+	mu        sync.Mutex
+	calls     map[string]int // by i=, t= or s= value
+	malformed bool           // reply with a truncated body
+	delay     time.Duration  // before every reply
+}
+
+func newFakeCatalog() *fakeOMDb {
+	f := &fakeOMDb{episodes: map[string]Movie{}, calls: map[string]int{}}
+	f.movies = append(f.movies, Movie{Title: "Inception", Year: "2010", Genre: "Action, Sci-Fi", Director: "Christopher Nolan", ImdbRating: "8.8", ImdbID: "tt1375666", Type: "movie", Runtime: "148 min", BoxOffice: "N/A", Rated: "N/A"})
+	for i := 1; i <= 25; i++ {
+		genre := "Drama"
+		if i%2 == 0 {
+			genre = "Comedy"
+		}
+		rating := fmt.Sprintf("%.1f", 4+float64(i%10)/2)
+		if i == 7 {
+			rating = "N/A"
+		}
+		f.movies = append(f.movies, Movie{Title: fmt.Sprintf("The Film %d", i), Year: strconv.Itoa(1990 + i), Genre: genre, ImdbRating: rating, ImdbID: fmt.Sprintf("tt%07d", i), Type: "movie"})
+	}
+	f.movies = append(f.movies, Movie{Title: "Breaking Bad", Year: "2008–2013", Genre: "Crime, Drama", ImdbRating: "9.5", ImdbID: "tt0903747", Type: "series", TotalSeasons: "5"})
+	f.episodes["breaking bad|1|1"] = Movie{Title: "Pilot", Season: "1", Episode: "1", Released: "20 Jan 2008", ImdbRating: "9.0", ImdbID: "tt0959621", Type: "episode"}
+	return f
+}
+
+func (f *fakeOMDb) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f.mu.Lock()
+	f.calls[q.Get("i")+q.Get("t")+q.Get("s")]++
+	malformed := f.malformed
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+	if q.Get("apikey") != "test" {
+		w.WriteHeader(401)
+		w.Write([]byte(`{"Response":"False","Error":"Invalid API key!"}`))
+		return
+	}
+	if malformed {
+		w.Write([]byte(`{"Title":"Incep`))
+		return
+	}
+	typ := q.Get("type")
+	switch {
+	case q.Get("i") != "":
+		for _, m := range f.movies {
+			if m.ImdbID == q.Get("i") {
+				f.reply(w, m)
+				return
+			}
+		}
+		f.reply(w, map[string]string{"Response": "False", "Error": "Incorrect IMDb ID."})
+	case q.Get("Season") != "":
+		if m, ok := f.episodes[strings.ToLower(q.Get("t"))+"|"+q.Get("Season")+"|"+q.Get("Episode")]; ok {
+			f.reply(w, m)
+			return
+		}
+		f.reply(w, map[string]string{"Response": "False", "Error": "Series or episode not found!"})
+	case q.Get("t") != "":
+		for _, m := range f.movies {
+			if strings.EqualFold(m.Title, q.Get("t")) && (typ == "" || m.Type == typ) {
+				f.reply(w, m)
+				return
+			}
+		}
+		f.reply(w, map[string]string{"Response": "False", "Error": "Movie not found!"})
+	case q.Get("s") != "":
+		var hits []searchItem
+		for _, m := range f.movies {
+			if strings.Contains(strings.ToLower(m.Title), strings.ToLower(q.Get("s"))) && (typ == "" || m.Type == typ) {
+				hits = append(hits, searchItem{Title: m.Title, Year: m.Year, ImdbID: m.ImdbID, Type: m.Type})
+			}
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		start := (max(page, 1) - 1) * omdbPageSize
+		if start >= len(hits) {
+			f.reply(w, map[string]string{"Response": "False", "Error": "Movie not found!"})
+			return
+		}
+		f.reply(w, searchResult{Search: hits[start:min(start+omdbPageSize, len(hits))], TotalResults: strconv.Itoa(len(hits)), Response: "True"})
+	default:
+		f.reply(w, map[string]string{"Response": "False", "Error": "Incorrect IMDb ID."})
+	}
+}
+
+func (f *fakeOMDb) reply(w http.ResponseWriter, v interface{}) {
+	if m, ok := v.(Movie); ok {
+		m.Response = "True"
+		v = m
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// callsFor is how many requests named v as i=, t= or s=.
+func (f *fakeOMDb) callsFor(v string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[v]
+}
+
+// startFakeOMDb serves f and points the package at it through
+// OMDB_BASE_URL, with the caches and breaker emptied and no rate limit.
+func startFakeOMDb(t *testing.T, f *fakeOMDb) {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	oldBase := omdbBaseURL
+	t.Cleanup(func() { omdbBaseURL = oldBase })
+	for k, v := range map[string]string{
+		"OMDB_API_KEY":     "test",
+		"OMDB_BASE_URL":    srv.URL,
+		"OMDB_RATE_LIMIT":  "100000",
+		"OMDB_RATE_BURST":  "100000",
+		"SERVICE_API_KEYS": "",
+		"ADMIN_TOKEN":      "",
+		"GIN_MODE":         "test",
+		"LOG_LEVEL":        "quiet",
+	} {
+		t.Setenv(k, v)
+	}
+	if err := loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	details = newDetailCache()
+	genreLists = &genreListCache{m: map[string]genreList{}}
+	omdbBreaker = &breaker{threshold: omdbBreaker.threshold, cooldown: omdbBreaker.cooldown}
+}
+
+// get runs a GET through the router and decodes the JSON reply into out.
+func get(t *testing.T, path string, out interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: %v in %q", path, err, w.Body.String())
+		}
+	}
+	return w.Code
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// movieFilter reports whether a movie should be kept. A nil filter keeps all.
+type movieFilter func(*Movie) bool
+
+// filterRule turns one query param into a movieFilter. Adding a filter to an
+// endpoint is a matter of adding a rule to its list.
+type filterRule struct {
+	param string
+	build func(v string) (movieFilter, error)
+}
+
+var completeOnlyRule = filterRule{"complete_only", func(v string) (movieFilter, error) {
+	if v != "true" {
+		return nil, nil
+	}
+	return isComplete, nil
+}}
+
+// filterWant is a build error that says what the param should be.
+type filterWant string
+
+func (w filterWant) Error() string { return string(w) }
+
+// parseRating reads an imdbRating bound, which must lie in 0-10.
+func parseRating(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 10 {
+		return 0, filterWant("a number from 0 to 10")
+	}
+	return f, nil
+}
+
+var minRatingRule = filterRule{"min_rating", func(v string) (movieFilter, error) {
+	min, err := parseRating(v)
+	if err != nil {
+		return nil, err
+	}
+	return func(m *Movie) bool { return hasRating(m) && ratingVal(m) >= min }, nil
+}}
+
+var maxRatingRule = filterRule{"max_rating", func(v string) (movieFilter, error) {
+	max, err := parseRating(v)
+	if err != nil {
+		return nil, err
+	}
+	return func(m *Movie) bool { return hasRating(m) && ratingVal(m) <= max }, nil
+}}
+
+// ratingMinRule and ratingMaxRule are the rating bounds under the names the
+// genre endpoint first used.
+var ratingMinRule = filterRule{"rating_min", minRatingRule.build}
+var ratingMaxRule = filterRule{"rating_max", maxRatingRule.build}
+
+var yearMinRule = filterRule{"year_min", func(v string) (movieFilter, error) {
+	y, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	return func(m *Movie) bool { return movieYear(m) >= y }, nil
+}}
+
+var yearMaxRule = filterRule{"year_max", func(v string) (movieFilter, error) {
+	y, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	return func(m *Movie) bool {
+		got := movieYear(m)
+		return got != 0 && got <= y
+	}, nil
+}}
+
+var genresRule = filterRule{"genres", func(v string) (movieFilter, error) {
+	want := splitList(v)
+	return func(m *Movie) bool { return hasAnyGenre(m, want) }, nil
+}}
+
+var excludeGenresRule = filterRule{"exclude_genres", func(v string) (movieFilter, error) {
+	skip := splitList(v)
+	return func(m *Movie) bool { return !hasAnyGenre(m, skip) }, nil
+}}
+
+var excludeIDsRule = filterRule{"exclude_ids", func(v string) (movieFilter, error) {
+	ids := map[string]bool{}
+	for _, id := range splitList(v) {
+		ids[id] = true
+	}
+	return func(m *Movie) bool { return !ids[strings.ToLower(m.ImdbID)] }, nil
+}}
+
+var genreFilterRules = []filterRule{completeOnlyRule, yearMinRule, yearMaxRule, ratingMinRule, ratingMaxRule, minRatingRule, maxRatingRule}
+
+var recommendFilterRules = []filterRule{
+	completeOnlyRule, minRatingRule, yearMinRule, yearMaxRule,
+	genresRule, excludeGenresRule, excludeIDsRule,
+}
+
+// parseFilters builds the chain of rules whose params are present on the
+// request. The chain keeps a movie only if every filter does. applied maps
+// each param used to its value, for echoing back to the client.
+func parseFilters(c *gin.Context, rules []filterRule) (keep movieFilter, applied map[string]string, err error) {
+	var chain []movieFilter
+	applied = map[string]string{}
+	for _, r := range rules {
+		v := strings.TrimSpace(c.Query(r.param))
+		if v == "" {
+			continue
+		}
+		f, err := r.build(v)
+		if err != nil {
+			w, _ := err.(filterWant)
+			return nil, nil, &paramError{r.param, v, string(w)}
+		}
+		if f != nil {
+			chain = append(chain, f)
+			applied[r.param] = v
+		}
+	}
+	return allOf(chain...), applied, nil
+}
+
+func allOf(fs ...movieFilter) movieFilter {
+	if len(fs) == 0 {
+		return nil
+	}
+	return func(m *Movie) bool {
+		for _, f := range fs {
+			if !f(m) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// isComplete reports whether the fields a result card needs are populated.
+func isComplete(m *Movie) bool {
+	for _, v := range []string{m.Poster, m.Plot, m.ImdbRating, m.Genre} {
+		if v == "" || v == "N/A" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseYear returns the first year in OMDb's Year field, which may be a
+// range such as "2011–2019" or open-ended "2011–". It returns 0 if none.
+func parseYear(v string) int {
+	if len(v) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(v[:4])
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+func movieYear(m *Movie) int { return parseYear(m.Year) }
+
+// spansYear reports whether OMDb's Year field v includes y: equal to it for
+// a single year, or within a range such as "2011–2019". An open-ended range
+// like "2011–" has no upper bound.
+func spansYear(v string, y int) bool {
+	from := parseYear(v)
+	if from == 0 || y < from {
+		return false
+	}
+	rest := strings.TrimLeft(v[4:], "–-") // en dash as OMDb writes it, or a hyphen
+	if rest == v[4:] {
+		return y == from
+	}
+	to := parseYear(rest)
+	return to == 0 || y <= to
+}
+
+func splitList(v string) []string {
+	out := []string{}
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func hasAnyGenre(m *Movie, genres []string) bool {
+	for _, have := range splitList(m.Genre) {
+		for _, want := range genres {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var sequelSuffix = regexp.MustCompile(`(?i)\s+(part\s+)?(\d+|ii|iii|iv|v|vi|vii|viii|ix|x)$`)
+
+// baseTitle reduces a title to the part its sequels share: lower case, no
+// subtitle after ":" or " - ", no leading article and no trailing sequel
+// number. "Toy Story 3" and "The Toy Story" both become "toy story".
+func baseTitle(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.IndexAny(t, ":("); i > 0 {
+		t = t[:i]
+	}
+	if i := strings.Index(t, " - "); i > 0 {
+		t = t[:i]
+	}
+	t = strings.TrimSpace(t)
+	for _, a := range []string{"the ", "a ", "an "} {
+		t = strings.TrimPrefix(t, a)
+	}
+	return strings.TrimSpace(sequelSuffix.ReplaceAllString(t, ""))
+}
+
+// sameFranchise reports whether title looks like base itself or a sequel to
+// it. A one-word base only matches exactly, so a seed like "Star" doesn't
+// swallow "Star Wars".
+func sameFranchise(base, title string) bool {
+	if base == "" {
+		return false
+	}
+	b := baseTitle(title)
+	if b == base {
+		return true
+	}
+	return strings.Contains(base, " ") && strings.HasPrefix(b, base+" ")
+}
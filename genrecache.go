@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// genreListTTL is how long a genre crawl's sorted results are kept for
+// paging through, set from GENRE_CACHE_TTL. Zero disables the cache.
+var genreListTTL = 5 * time.Minute
+
+// genreListMax caps how many crawls are held at once.
+const genreListMax = 100
+
+type genreList struct {
+	movies  []*Movie
+	stats   collectStats
+	expires time.Time
+}
+
+// genreListCache holds the full ranked result of recent genre crawls, keyed
+// by everything in the query that changes which movies come back or their
+// order, so any page of the same listing is served without crawling again.
+// Like posterCache it drops expired entries first when full, then any.
+type genreListCache struct {
+	mu sync.Mutex
+	m  map[string]genreList
+}
+
+var genreLists = &genreListCache{m: map[string]genreList{}}
+
+// genrePagingParams don't affect the list itself, only what is shown of it.
+var genrePagingParams = []string{"limit", "offset", "page", "page_size", "debug", "fields", "format"}
+
+// genreListKey is q without the paging params, encoded in sorted order.
+func genreListKey(q url.Values) string {
+	k := url.Values{}
+	for n, v := range q {
+		k[n] = v
+	}
+	for _, n := range genrePagingParams {
+		k.Del(n)
+	}
+	return k.Encode()
+}
+
+func (gc *genreListCache) get(key string) (genreList, bool) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	e, ok := gc.m[key]
+	if ok && time.Now().After(e.expires) {
+		delete(gc.m, key)
+		return genreList{}, false
+	}
+	return e, ok
+}
+
+func (gc *genreListCache) put(key string, movies []*Movie, stats collectStats) {
+	if genreListTTL <= 0 {
+		return
+	}
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	now := time.Now()
+	if _, ok := gc.m[key]; !ok && len(gc.m) >= genreListMax {
+		for k, e := range gc.m {
+			if now.After(e.expires) {
+				delete(gc.m, k)
+			}
+		}
+		for k := range gc.m {
+			if len(gc.m) < genreListMax {
+				break
+			}
+			delete(gc.m, k)
+		}
+	}
+	gc.m[key] = genreList{movies: movies, stats: stats, expires: now.Add(genreListTTL)}
+}
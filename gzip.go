@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipMinSize is the smallest body worth compressing, set from
+// GZIP_MIN_SIZE; below it the gzip header and CPU cost outweigh the
+// savings. gzipLevel is set from GZIP_LEVEL, 1 (fastest) to 9 (smallest).
+var (
+	gzipMinSize = 1024
+	gzipLevel   = gzip.DefaultCompression
+)
+
+// gzipWriter buffers a response so gzipResponses can decide, once the body
+// is complete, whether to compress it and set Content-Length to match.
+// Images and bodies that already carry an encoding are passed straight
+// through instead.
+type gzipWriter struct {
+	gin.ResponseWriter
+	buf    bytes.Buffer
+	status int
+	pass   bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if w.pass {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.pass {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *gzipWriter) Status() int {
+	if !w.pass && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.pass {
+		h := w.Header()
+		if !strings.HasPrefix(h.Get("Content-Type"), "image/") && h.Get("Content-Encoding") == "" {
+			return w.buf.Write(b)
+		}
+		w.passThrough()
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) { return w.Write([]byte(s)) }
+
+// Flush is a no-op while buffering; nothing here streams.
+func (w *gzipWriter) Flush() {
+	if w.pass {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// passThrough stops buffering, writing out the status and anything held.
+func (w *gzipWriter) passThrough() {
+	w.pass = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// finish writes the buffered body, gzipped if it is large enough.
+func (w *gzipWriter) finish() {
+	if w.pass {
+		return
+	}
+	h := w.Header()
+	h.Add("Vary", "Accept-Encoding")
+	if w.buf.Len() >= gzipMinSize {
+		var zb bytes.Buffer
+		gz, _ := gzip.NewWriterLevel(&zb, gzipLevel)
+		if _, err := gz.Write(w.buf.Bytes()); err == nil && gz.Close() == nil {
+			h.Set("Content-Encoding", "gzip")
+			h.Set("Content-Length", strconv.Itoa(zb.Len()))
+			w.buf = zb
+		}
+	}
+	w.passThrough()
+}
+
+// gzipResponses compresses bodies of at least gzipMinSize bytes for clients
+// that send Accept-Encoding: gzip.
+func gzipResponses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == "HEAD" || !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		w := &gzipWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const healthProbeID = "tt0111161"
+const healthCacheTTL = 30 * time.Second
+
+type healthResult struct {
+	ok        bool
+	detail    string
+	latency   time.Duration
+	checkedAt time.Time
+}
+
+var healthMu sync.Mutex
+var lastHealth healthResult
+
+// probeOMDB looks up a fixed title to check that OMDb is reachable and the key
+// is accepted. Results are reused for healthCacheTTL so frequent checks don't
+// eat into the quota.
+func probeOMDB(ctx context.Context) healthResult {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if !lastHealth.checkedAt.IsZero() && time.Since(lastHealth.checkedAt) < healthCacheTTL {
+		return lastHealth
+	}
+	start := time.Now()
+	var m Movie
+	err := fetchJSON(ctx, omdbURL(map[string]string{"i": healthProbeID}), &m)
+	res := healthResult{latency: time.Since(start), checkedAt: time.Now()}
+	switch {
+	case err != nil:
+		res.detail = err.Error()
+	case !m.ok():
+		res.detail = m.Error
+	default:
+		res.ok = true
+	}
+	if ctx.Err() == nil {
+		lastHealth = res
+	}
+	return res
+}
+
+// checkAPIKey makes one lookup at startup so a rejected OMDB_API_KEY stops
+// the server instead of failing every request. Only a rejection is an
+// error; if OMDb can't be reached or the quota is spent the key may well be
+// fine, so that is just logged.
+func checkAPIKey(ctx context.Context) error {
+	var m Movie
+	err := fetchJSON(ctx, omdbURL(map[string]string{"i": healthProbeID}), &m)
+	var se *statusError
+	switch {
+	case errors.As(err, &se) && se.code == 401, err == nil && strings.Contains(m.Error, "API key"):
+		msg := m.Error
+		if msg == "" {
+			msg = "HTTP 401"
+		}
+		return fmt.Errorf("OMDb rejected OMDB_API_KEY (%s); set SKIP_KEY_CHECK=true to start anyway", msg)
+	case err != nil:
+		log.Printf("key check: %v; starting anyway", err)
+	}
+	return nil
+}
+
+// healthHandler is GET /api/health, the detailed probe for dashboards.
+//
+//	@Summary	OMDb reachability and key check
+//	@Tags	health
+//	@Produce	json
+//	@Success	200	{object}	healthResponse
+//	@Failure	503	{object}	healthResponse
+//	@Security	APIKey
+//	@Router	/api/health [get]
+func healthHandler(c *gin.Context) {
+	h := probeOMDB(c.Request.Context())
+	body := healthResponse{
+		Status:         "ok",
+		OMDBLatencyMs:  h.latency.Milliseconds(),
+		CheckedAt:      h.checkedAt.Format(time.RFC3339),
+		OMDBRateTokens: math.Floor(omdbLimiter.available()),
+		Breaker:        omdbBreaker.status(),
+	}
+	if !h.ok {
+		body.Status, body.Error = "unavailable", h.detail
+		c.JSON(503, body)
+		return
+	}
+	c.JSON(200, body)
+}
+
+// healthzHandler is the liveness probe: the process is up and serving.
+//
+//	@Summary	Liveness probe
+//	@Tags	health
+//	@Produce	json
+//	@Success	200	{object}	statusResponse
+//	@Router	/healthz [get]
+func healthzHandler(c *gin.Context) {
+	c.JSON(200, statusResponse{Status: "ok"})
+}
+
+// readyzHandler is the readiness probe. It shares probeOMDB's cached result
+// with /api/health.
+//
+//	@Summary	Readiness probe
+//	@Tags	health
+//	@Produce	json
+//	@Success	200	{object}	statusResponse
+//	@Failure	503	{object}	statusResponse
+//	@Router	/readyz [get]
+func readyzHandler(c *gin.Context) {
+	h := probeOMDB(c.Request.Context())
+	if !h.ok {
+		c.JSON(503, statusResponse{"unavailable", h.detail})
+		return
+	}
+	c.JSON(200, statusResponse{Status: "ok"})
+}
@@ -1,326 +1,1866 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
-	"os"
-	"sort"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-)
-
-var apiKey string
-var httpClient = &http.Client{Timeout: 10 * time.Second}
-
-type searchItem struct {
-	Title  string `json:"Title"`
-	ImdbID string `json:"imdbID"`
-	Type   string `json:"Type"`
-}
-type searchResult struct {
-	Search   []searchItem `json:"Search"`
-	Response string       `json:"Response"`
-	Error    string       `json:"Error"`
-}
-
-func main() {
-	_ = godotenv.Load()
-	apiKey = os.Getenv("OMDB_API_KEY")
-	if apiKey == "" {
-		fmt.Println("OMDB_API_KEY missing in .env")
-		return
-	}
-	r := gin.Default()
-	r.GET("/api/movie", movieHandler)
-	r.GET("/api/episode", episodeHandler)
-	r.GET("/api/movies/genre", moviesByGenreHandler)
-	r.GET("/api/recommend", recommendHandler)
-	r.Run(":8080")
-}
-
-func omdbURL(params map[string]string) string {
-	v := url.Values{}
-	v.Set("apikey", apiKey)
-	for k, val := range params {
-		v.Set(k, val)
-	}
-	return "https://www.omdbapi.com/?" + v.Encode()
-}
-
-func fetchJSON(u string, out interface{}) error {
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header.Set("User-Agent", "go-movie-api/1.0")
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("status %d", resp.StatusCode)
-	}
-	return json.NewDecoder(resp.Body).Decode(out)
-}
-
-func movieHandler(c *gin.Context) {
-	t := c.Query("title")
-	if t == "" {
-		c.JSON(400, gin.H{"error": "missing title"})
-		return
-	}
-	u := omdbURL(map[string]string{"t": t, "plot": "full"})
-	var m map[string]interface{}
-	if err := fetchJSON(u, &m); err != nil || m["Response"] == "False" {
-		c.JSON(404, gin.H{"error": "movie not found"})
-		return
-	}
-	c.JSON(200, gin.H{
-		"Title":    m["Title"],
-		"Year":     m["Year"],
-		"Plot":     m["Plot"],
-		"Country":  m["Country"],
-		"Awards":   m["Awards"],
-		"Director": m["Director"],
-		"Ratings":  m["Ratings"],
-	})
-}
-
-func episodeHandler(c *gin.Context) {
-	s := c.Query("series_title")
-	se := c.Query("season")
-	e := c.Query("episode_number")
-	if s == "" || se == "" || e == "" {
-		c.JSON(400, gin.H{"error": "missing parameters"})
-		return
-	}
-	u := omdbURL(map[string]string{"t": s, "Season": se, "Episode": e, "plot": "full"})
-	var m map[string]interface{}
-	if err := fetchJSON(u, &m); err != nil || m["Response"] == "False" {
-		c.JSON(404, gin.H{"error": "episode not found"})
-		return
-	}
-	c.JSON(200, gin.H{
-		"Title":      m["Title"],
-		"Season":     m["Season"],
-		"Episode":    m["Episode"],
-		"Released":   m["Released"],
-		"Plot":       m["Plot"],
-		"imdbRating": m["imdbRating"],
-	})
-}
-
-func searchByKeyword(keyword string, page int) []searchItem {
-	u := omdbURL(map[string]string{"s": keyword, "page": strconv.Itoa(page)})
-	var sr searchResult
-	if err := fetchJSON(u, &sr); err != nil || sr.Response == "False" {
-		return nil
-	}
-	return sr.Search
-}
-
-func getDetailByID(id string) (map[string]interface{}, error) {
-	u := omdbURL(map[string]string{"i": id, "plot": "short"})
-	var md map[string]interface{}
-	if err := fetchJSON(u, &md); err != nil || md["Response"] == "False" {
-		return nil, fmt.Errorf("not found")
-	}
-	return md, nil
-}
-
-func getDetailByTitle(title string) (map[string]interface{}, error) {
-	u := omdbURL(map[string]string{"t": title, "plot": "short"})
-	var md map[string]interface{}
-	if err := fetchJSON(u, &md); err == nil {
-		if md["Response"] == "True" {
-			return md, nil
-		}
-	}
-	for p := 1; p <= 2; p++ {
-		items := searchByKeyword(title, p)
-		if items == nil {
-			continue
-		}
-		for _, it := range items {
-			if it.ImdbID == "" {
-				continue
-			}
-			if m, err := getDetailByID(it.ImdbID); err == nil {
-				return m, nil
-			}
-		}
-	}
-	return nil, fmt.Errorf("not found")
-}
-
-func collectByGenre(gen string, limit int) []map[string]interface{} {
-	found := map[string]map[string]interface{}{}
-	kw := []string{"the", "a", "man", "love", "star", "dark", "king", "matrix", "avengers"}
-	for _, k := range kw {
-		items := searchByKeyword(k, 1)
-		if items == nil {
-			continue
-		}
-		for _, it := range items {
-			if it.ImdbID == "" {
-				continue
-			}
-			if _, ok := found[it.ImdbID]; ok {
-				continue
-			}
-			md, err := getDetailByID(it.ImdbID)
-			if err != nil {
-				continue
-			}
-			if g, ok := md["Genre"].(string); ok && strings.Contains(strings.ToLower(g), strings.ToLower(gen)) {
-				found[it.ImdbID] = md
-				if len(found) >= limit {
-					break
-				}
-			}
-		}
-		if len(found) >= limit {
-			break
-		}
-	}
-	out := make([]map[string]interface{}, 0, len(found))
-	for _, v := range found {
-		out = append(out, v)
-	}
-	return out
-}
-
-func ratingVal(m map[string]interface{}) float64 {
-	if r, ok := m["imdbRating"].(string); ok && r != "N/A" && r != "" {
-		if f, err := strconv.ParseFloat(r, 64); err == nil {
-			return f
-		}
-	}
-	return 0
-}
-
-func topByRating(list []map[string]interface{}, n int) []map[string]interface{} {
-	sort.Slice(list, func(i, j int) bool { return ratingVal(list[i]) > ratingVal(list[j]) })
-	if len(list) > n {
-		return list[:n]
-	}
-	return list
-}
-
-func moviesByGenreHandler(c *gin.Context) {
-	genre := c.Query("genre")
-	if genre == "" {
-		c.JSON(400, gin.H{"error": "missing genre"})
-		return
-	}
-	cands := collectByGenre(genre, 150)
-	top := topByRating(cands, 15)
-	out := make([]gin.H, 0, len(top))
-	for _, m := range top {
-		out = append(out, gin.H{
-			"Title":      m["Title"],
-			"Year":       m["Year"],
-			"imdbID":     m["imdbID"],
-			"Genre":      m["Genre"],
-			"imdbRating": m["imdbRating"],
-		})
-	}
-	c.JSON(200, gin.H{"genre": genre, "count": len(out), "movies": out})
-}
-
-func recommendHandler(c *gin.Context) {
-	fav := c.Query("favorite_movie")
-	if fav == "" {
-		c.JSON(400, gin.H{"error": "missing favorite_movie"})
-		return
-	}
-	seed, err := getDetailByTitle(fav)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "favorite movie not found"})
-		return
-	}
-	perLevel := 20
-	seen := map[string]bool{}
-	if id, ok := seed["imdbID"].(string); ok && id != "" {
-		seen[id] = true
-	}
-	result := []map[string]interface{}{}
-	if g, ok := seed["Genre"].(string); ok {
-		for _, gg := range strings.Split(g, ",") {
-			gg = strings.TrimSpace(gg)
-			cands := topByRating(collectByGenre(gg, perLevel), perLevel)
-			for _, m := range cands {
-				if id, ok := m["imdbID"].(string); ok && !seen[id] {
-					seen[id] = true
-					result = append(result, m)
-					if len(result) >= perLevel {
-						break
-					}
-				}
-			}
-			if len(result) >= perLevel {
-				break
-			}
-		}
-	}
-	if len(result) < perLevel {
-		if d, ok := seed["Director"].(string); ok {
-			for _, dir := range strings.Split(d, ",") {
-				dir = strings.TrimSpace(dir)
-				cands := topByRating(collectByGenre(dir, perLevel), perLevel) // small fallback: genre-like by director name search
-				for _, m := range cands {
-					if id, ok := m["imdbID"].(string); ok && !seen[id] {
-						seen[id] = true
-						result = append(result, m)
-						if len(result) >= perLevel {
-							break
-						}
-					}
-				}
-				if len(result) >= perLevel {
-					break
-				}
-			}
-		}
-	}
-	if len(result) < perLevel {
-		if a, ok := seed["Actors"].(string); ok {
-			for _, actor := range strings.Split(a, ",") {
-				actor = strings.TrimSpace(actor)
-				cands := topByRating(collectByGenre(actor, perLevel), perLevel) // fallback
-				for _, m := range cands {
-					if id, ok := m["imdbID"].(string); ok && !seen[id] {
-						seen[id] = true
-						result = append(result, m)
-						if len(result) >= perLevel {
-							break
-						}
-					}
-				}
-				if len(result) >= perLevel {
-					break
-				}
-			}
-		}
-	}
-	if len(result) > perLevel {
-		result = result[:perLevel]
-	}
-	out := make([]gin.H, 0, len(result))
-	for _, m := range result {
-		out = append(out, gin.H{
-			"Title":      m["Title"],
-			"Year":       m["Year"],
-			"imdbID":     m["imdbID"],
-			"Genre":      m["Genre"],
-			"Director":   m["Director"],
-			"Actors":     m["Actors"],
-			"imdbRating": m["imdbRating"],
-		})
-	}
-	c.JSON(200, gin.H{"favorite_movie": seed["Title"], "recommendations": out})
-}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	_ "github.com/you/backend-postman/docs"
+)
+
+var apiKey string
+var errNotFound = errors.New("not found")
+
+// errQuotaExceeded means OMDb refused the call because the key's daily
+// request limit is used up. Unlike errRateLimited, waiting a few seconds
+// won't help.
+var errQuotaExceeded = errors.New("omdb request limit reached")
+var omdbBaseURL = "https://www.omdbapi.com/"
+
+// httpClient has no timeout of its own; each call is bounded by
+// omdbCallTimeout and the request's deadline through its context. Its
+// transport is rebuilt by loadConfig from the OMDB_*_CONNS settings.
+var httpClient = &http.Client{Transport: newTransport(32, 0, 90*time.Second)}
+
+// newTransport keeps up to idle connections per host open for idleTimeout.
+// Every OMDb call goes to one host, so the default of 2 idle connections
+// made concurrent crawls dial a new connection for most requests. maxConns
+// caps open connections per host; 0 means no cap.
+func newTransport(idle, maxConns int, idleTimeout time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = idle
+	t.MaxIdleConnsPerHost = idle
+	t.MaxConnsPerHost = maxConns
+	t.IdleConnTimeout = idleTimeout
+	return t
+}
+
+// omdbCallTimeout bounds a single OMDb attempt, set from OMDB_CALL_TIMEOUT.
+var omdbCallTimeout = 5 * time.Second
+
+// logOMDBCalls logs every upstream attempt under the request's ID, set with
+// LOG_OMDB_CALLS=true. Failures are always logged.
+var logOMDBCalls bool
+
+// maxAttempts is how many times fetchJSON tries a call, set from
+// OMDB_MAX_RETRIES as one more than the retries.
+var maxAttempts = 3
+var retryBaseDelay = 200 * time.Millisecond
+
+type searchItem struct {
+	Title        string `json:"Title"`
+	Year         string `json:"Year"`
+	ImdbID       string `json:"imdbID"`
+	Type         string `json:"Type"`
+	TotalSeasons string `json:"totalSeasons,omitempty"`
+	ImdbRating   string `json:"imdbRating,omitempty"`
+}
+
+// movie is the item as far as the sort keys need it.
+func (it searchItem) movie() *Movie {
+	return &Movie{Title: it.Title, Year: it.Year, ImdbID: it.ImdbID, Type: it.Type, ImdbRating: it.ImdbRating}
+}
+
+type seasonEpisode struct {
+	Title      string `json:"Title"`
+	Released   string `json:"Released"`
+	Episode    string `json:"Episode"`
+	ImdbRating string `json:"imdbRating"`
+	ImdbID     string `json:"imdbID"`
+}
+type seasonResult struct {
+	Title        string          `json:"Title"`
+	Season       string          `json:"Season"`
+	TotalSeasons string          `json:"totalSeasons"`
+	Episodes     []seasonEpisode `json:"Episodes"`
+	Response     interface{}     `json:"Response"`
+	Error        string          `json:"Error"`
+}
+type searchResult struct {
+	Search       []searchItem `json:"Search"`
+	TotalResults string       `json:"totalResults"`
+	Response     interface{}  `json:"Response"`
+	Error        string       `json:"Error"`
+}
+
+// The spec under docs/ is generated from the annotations on main and the
+// handlers; rerun go generate after changing a route or response type.
+//
+//	@title						Postman backend API
+//	@version					1.0
+//	@description				Movie, series and recommendation lookups backed by OMDb. Errors share one envelope: {"error": {"code", "message", "details"}}. Send Accept: application/xml or format=xml for XML with the same element names.
+//	@BasePath					/
+//	@securityDefinitions.apikey	AdminToken
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer " followed by ADMIN_TOKEN.
+//	@securityDefinitions.apikey	APIKey
+//	@in							header
+//	@name						X-API-Key
+//	@description				One of SERVICE_API_KEYS, required on /api/* when that is set.
+//
+//go:generate swag init --outputTypes go,json,yaml
+func main() {
+	_ = godotenv.Load()
+	if err := loadConfig(); err != nil {
+		log.Fatal(err)
+	}
+	if v := os.Getenv("SKIP_KEY_CHECK"); v != "true" && v != "1" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := checkAPIKey(ctx)
+		cancel()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	dbPath := os.Getenv("WATCHLIST_DB")
+	if dbPath == "" {
+		dbPath = "watchlist.db"
+	}
+	if err := openWatchlist(dbPath); err != nil {
+		log.Fatal("watchlist: ", err)
+	}
+	defer watchlistDB.Close()
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	r := newRouter()
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+	<-ctx.Done()
+	stop()
+	log.Println("shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+		return
+	}
+	log.Println("shutdown complete")
+}
+
+// loadConfig applies the environment to the package settings.
+func loadConfig() error {
+	apiKey = os.Getenv("OMDB_API_KEY")
+	if apiKey == "" {
+		return errors.New("OMDB_API_KEY missing in .env")
+	}
+	if v, err := strconv.Atoi(os.Getenv("OMDB_MAX_RETRIES")); err == nil && v >= 0 {
+		maxAttempts = v + 1
+	}
+	if v, err := time.ParseDuration(os.Getenv("OMDB_RETRY_BASE_DELAY")); err == nil && v > 0 {
+		retryBaseDelay = v
+	}
+	if v := os.Getenv("OMDB_BASE_URL"); v != "" {
+		base, err := normalizeBaseURL(v)
+		if err != nil {
+			return fmt.Errorf("invalid OMDB_BASE_URL: %w", err)
+		}
+		omdbBaseURL = base
+	}
+	rps, burst := 10.0, 20
+	if v, err := strconv.ParseFloat(os.Getenv("OMDB_RATE_LIMIT"), 64); err == nil && v > 0 {
+		rps = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OMDB_RATE_BURST")); err == nil && v > 0 {
+		burst = v
+	}
+	omdbLimiter = newTokenBucket(rps, burst)
+	if v, err := time.ParseDuration(os.Getenv("OMDB_RATE_MAX_WAIT")); err == nil && v >= 0 {
+		omdbLimiter.maxWait = v
+	}
+	switch v := os.Getenv("DEFAULT_PLOT"); v {
+	case "":
+	case "short", "full":
+		defaultPlot = v
+	default:
+		return fmt.Errorf("invalid DEFAULT_PLOT %q, want short or full", v)
+	}
+	loadResponseFormats(os.Getenv("RESPONSE_FORMATS_ENABLED"))
+	if v, err := strconv.Atoi(os.Getenv("GZIP_MIN_SIZE")); err == nil && v >= 0 {
+		gzipMinSize = v
+	}
+	if v := os.Getenv("GZIP_LEVEL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 9 {
+			return fmt.Errorf("invalid GZIP_LEVEL %q, want 1 to 9", v)
+		}
+		gzipLevel = n
+	}
+	loadSeedKeywords()
+	if v, err := time.ParseDuration(os.Getenv("CACHE_TTL")); err == nil && v >= 0 {
+		detailTTL = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("CACHE_MAX_ENTRIES")); err == nil && v >= 0 {
+		detailCacheMax = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("NEGATIVE_CACHE_TTL")); err == nil && v >= 0 {
+		negativeTTL = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("GENRE_CACHE_TTL")); err == nil && v >= 0 {
+		genreListTTL = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("POSTER_CACHE_TTL")); err == nil && v >= 0 {
+		posterTTL = v
+	}
+	adminToken = os.Getenv("ADMIN_TOKEN")
+	loadServiceKeys(os.Getenv("SERVICE_API_KEYS"))
+	logOMDBCalls = os.Getenv("LOG_OMDB_CALLS") == "true"
+	if err := loadLogging(os.Getenv("GIN_MODE"), os.Getenv("LOG_LEVEL"), os.Getenv("APP_ENV")); err != nil {
+		return err
+	}
+	if v := os.Getenv("METRICS_PATH"); v != "" {
+		if !strings.HasPrefix(v, "/") {
+			v = "/" + v
+		}
+		metricsPath = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OMDB_BREAKER_THRESHOLD")); err == nil && v >= 0 {
+		omdbBreaker.threshold = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("OMDB_BREAKER_COOLDOWN")); err == nil && v > 0 {
+		omdbBreaker.cooldown = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("OMDB_CALL_TIMEOUT")); err == nil && v > 0 {
+		omdbCallTimeout = v
+	}
+	idle, maxConns, idleTimeout := 32, 0, 90*time.Second
+	if v, err := strconv.Atoi(os.Getenv("OMDB_MAX_IDLE_CONNS")); err == nil && v > 0 {
+		idle = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OMDB_MAX_CONNS")); err == nil && v >= 0 {
+		maxConns = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("OMDB_IDLE_CONN_TIMEOUT")); err == nil && v > 0 {
+		idleTimeout = v
+	}
+	httpClient.Transport = newTransport(idle, maxConns, idleTimeout)
+	if v, err := time.ParseDuration(os.Getenv("REQUEST_BUDGET")); err == nil && v > 0 {
+		crawlDeadline = v
+	}
+	loadAllowedOrigins(os.Getenv("ALLOWED_ORIGINS"))
+	if v, err := time.ParseDuration(os.Getenv("CORS_MAX_AGE")); err == nil && v >= 0 {
+		corsMaxAge = v
+	}
+	return nil
+}
+
+// loadLogging sets gin's mode and how much we log. mode is GIN_MODE, and
+// defaults to release when APP_ENV is production and to debug otherwise.
+// level is LOG_LEVEL: debug also logs every OMDb call, info (the default)
+// writes the access log, and quiet drops it.
+func loadLogging(mode, level, env string) error {
+	switch mode {
+	case "":
+		mode = gin.DebugMode
+		if strings.EqualFold(env, "production") {
+			mode = gin.ReleaseMode
+		}
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		return fmt.Errorf("invalid GIN_MODE %q, want debug, release or test", mode)
+	}
+	gin.SetMode(mode)
+	switch strings.ToLower(level) {
+	case "", "info":
+	case "debug":
+		logOMDBCalls = true
+	case "quiet":
+		quietLogs = true
+	default:
+		return fmt.Errorf("invalid LOG_LEVEL %q, want debug, info or quiet", level)
+	}
+	return nil
+}
+
+// newRouter wires every route and middleware; main only adds the server.
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(requestLogger(), metrics(), gin.Recovery(), cors(), gzipResponses())
+	api := r.Group("/api", requireAPIKey(), etags())
+	api.GET("/movie", withDeadline(lookupDeadline), movieHandler)
+	api.GET("/movie/id/:imdbID", withDeadline(lookupDeadline), movieByIDHandler)
+	api.GET("/episode", withDeadline(lookupDeadline), episodeHandler)
+	api.GET("/season", withDeadline(lookupDeadline), seasonHandler)
+	api.GET("/series", withDeadline(lookupDeadline), seriesHandler)
+	api.GET("/movies/genre", withDeadline(crawlDeadline), moviesByGenreHandler)
+	api.GET("/movies/year", withDeadline(crawlDeadline), moviesByYearHandler)
+	api.POST("/movies", withDeadline(crawlDeadline), batchMoviesHandler)
+	api.POST("/movies/batch", withDeadline(crawlDeadline), batchMoviesHandler)
+	api.GET("/recommend", withDeadline(crawlDeadline), recommendHandler)
+	api.GET("/random", withDeadline(crawlDeadline), randomHandler)
+	api.GET("/compare", withDeadline(lookupDeadline), compareHandler)
+	api.GET("/actor/filmography", withDeadline(crawlDeadline), filmographyHandler)
+	api.GET("/search", withDeadline(lookupDeadline), searchHandler)
+	api.GET("/poster", withDeadline(lookupDeadline), posterHandler)
+	api.GET("/poster/:imdbID", withDeadline(lookupDeadline), posterHandler)
+	api.POST("/watchlist", withDeadline(lookupDeadline), addWatchHandler)
+	api.GET("/watchlist", withDeadline(lookupDeadline), listWatchHandler)
+	api.DELETE("/watchlist/:imdbID", deleteWatchHandler)
+	api.GET("/health", withDeadline(lookupDeadline), healthHandler)
+	api.GET("/stats", statsHandler)
+	if adminToken != "" {
+		api.POST("/admin/warm", requireAdmin(), warmHandler)
+	}
+	r.GET("/healthz", healthzHandler)
+	r.GET(metricsPath, gin.WrapH(promhttp.Handler()))
+	r.GET("/readyz", withDeadline(lookupDeadline), readyzHandler)
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.NoRoute(func(c *gin.Context) { respondError(c, 404, codeNotFound, "no such endpoint") })
+	return r
+}
+
+// normalizeBaseURL checks that v is an absolute http(s) URL and gives it a
+// trailing slash, so values like an httptest server's URL work as-is.
+func normalizeBaseURL(v string) (string, error) {
+	pu, err := url.Parse(v)
+	if err != nil {
+		return "", err
+	}
+	if (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute http(s) URL", v)
+	}
+	pu.RawQuery = ""
+	pu.Fragment = ""
+	if !strings.HasSuffix(pu.Path, "/") {
+		pu.Path += "/"
+	}
+	return pu.String(), nil
+}
+
+func omdbURL(params map[string]string) string {
+	v := url.Values{}
+	v.Set("apikey", apiKey)
+	for k, val := range params {
+		v.Set(k, val)
+	}
+	return omdbBaseURL + "?" + v.Encode()
+}
+
+// redactURL strips the apikey so URLs are safe to put in errors and logs.
+func redactURL(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	q := pu.Query()
+	q.Del("apikey")
+	pu.RawQuery = q.Encode()
+	return pu.String()
+}
+
+// maxRetryAfter caps how long we honor an upstream Retry-After header.
+const maxRetryAfter = 5 * time.Second
+
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("status %d", e.code) }
+
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << (attempt - 1)
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// retryDelay is the backoff for attempt, stretched to a 429's Retry-After.
+func retryDelay(attempt int, err error) time.Duration {
+	d := backoff(attempt)
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > d {
+		d = min(se.retryAfter, maxRetryAfter)
+	}
+	return d
+}
+
+func fetchJSON(ctx context.Context, u string, out interface{}) error {
+	probe, err := omdbBreaker.allow()
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", redactURL(u), err)
+	}
+	defer func() { omdbBreaker.record(ctx, probe, err) }()
+	defer func(start time.Time) { omdbFetchDuration.Observe(time.Since(start).Seconds()) }(time.Now())
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = fetchOnce(ctx, u, out); err == nil || !retry || attempt >= maxAttempts {
+			break
+		}
+		select {
+		case <-time.After(retryDelay(attempt, err)):
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// The caller gave up, e.g. a recommend crawl that is no longer needed.
+		return fmt.Errorf("fetch %s: %w", redactURL(u), ctx.Err())
+	}
+	if err != nil {
+		omdbErrors.Inc()
+		logEvent(ctx, map[string]interface{}{"level": "error", "msg": "omdb fetch failed", "url": redactURL(u), "error": err.Error()})
+		return fmt.Errorf("fetch %s: %w", redactURL(u), err)
+	}
+	return nil
+}
+
+// fetchOnce performs a single request and reports whether a failure is
+// worth retrying (network errors, 429 and 5xx).
+func fetchOnce(ctx context.Context, u string, out interface{}) (bool, error) {
+	if err := omdbLimiter.wait(ctx); err != nil {
+		return false, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, omdbCallTimeout)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(cctx, "GET", u, nil)
+	req.Header.Set("User-Agent", "go-movie-api/1.0")
+	if id := requestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	countUpstreamCall(ctx)
+	omdbCalls.Inc()
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	recordOMDBCall(u, time.Since(start))
+	if logOMDBCalls {
+		ev := map[string]interface{}{"level": "debug", "msg": "omdb call", "url": redactURL(u), "latency_ms": time.Since(start).Milliseconds()}
+		if err != nil {
+			ev["error"] = err.Error()
+		} else {
+			ev["status"] = resp.StatusCode
+		}
+		logEvent(ctx, ev)
+	}
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		// An exhausted quota comes back as a 401 with the usual error body.
+		var body struct{ Error string }
+		if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil && isQuotaError(body.Error) {
+			return false, errQuotaExceeded
+		}
+		se := &statusError{code: resp.StatusCode}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			se.retryAfter = time.Duration(secs) * time.Second
+		}
+		return se.code == 429 || se.code >= 500, se
+	}
+	return false, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// isOMDBSuccess reports whether an OMDb reply is a hit. Response is normally
+// the string "True"/"False" but a bool is accepted too, and any Error text
+// counts as a failure.
+func isOMDBSuccess(response interface{}, errMsg string) bool {
+	if errMsg != "" {
+		return false
+	}
+	switch v := response.(type) {
+	case string:
+		return strings.EqualFold(v, "true")
+	case bool:
+		return v
+	}
+	return false
+}
+
+func (sr seasonResult) ok() bool { return isOMDBSuccess(sr.Response, sr.Error) }
+
+func (sr searchResult) ok() bool { return isOMDBSuccess(sr.Response, sr.Error) }
+
+func isQuotaError(msg string) bool { return strings.Contains(msg, "limit reached") }
+
+// omdbError is the error for an unsuccessful OMDb reply: errQuotaExceeded
+// for an exhausted quota and errNotFound for anything else.
+func omdbError(msg string) error {
+	if isQuotaError(msg) {
+		return errQuotaExceeded
+	}
+	return errNotFound
+}
+
+// omdbErrorStatus picks the status for an unsuccessful OMDb reply from
+// its Error text. Key problems are ours, not the caller's, and an
+// exhausted quota is a 429.
+func omdbErrorStatus(msg string) int {
+	switch {
+	case isQuotaError(msg):
+		return 429
+	case strings.Contains(msg, "API key"):
+		return 502
+	case strings.HasPrefix(msg, "Too many results"):
+		return 400
+	}
+	return 404
+}
+
+// respondFetchError reports a failed lookup. Only errNotFound, meaning OMDb
+// answered and has no such title, is a 404 with fallback as the message.
+// Hitting our own rate limit is a 429 with Retry-After, and an OMDb that
+// errored, returned a non-200 or couldn't be reached is a 502.
+func respondFetchError(c *gin.Context, err error, fallback string) {
+	if timedOut(c) {
+		return
+	}
+	if errors.Is(err, errRateLimited) {
+		wait := int(math.Ceil(max(omdbLimiter.untilToken().Seconds(), 1)))
+		c.Header("Retry-After", strconv.Itoa(wait))
+		respondErrorDetails(c, 429, codeRateLimited, "OMDb rate limit reached, retry later", gin.H{"retry_after_seconds": wait})
+		return
+	}
+	if errors.Is(err, errQuotaExceeded) {
+		respondQuotaExceeded(c)
+		return
+	}
+	if errors.Is(err, errCircuitOpen) {
+		wait := int(math.Ceil(max(omdbBreaker.retryAfter().Seconds(), 1)))
+		c.Header("Retry-After", strconv.Itoa(wait))
+		respondErrorDetails(c, 503, codeUpstream, "OMDb is failing, retry later", gin.H{"retry_after_seconds": wait})
+		return
+	}
+	if errors.Is(err, errNotFound) {
+		respondError(c, 404, codeNotFound, fallback)
+		return
+	}
+	msg := "could not reach OMDb"
+	var se *statusError
+	if errors.As(err, &se) {
+		msg = fmt.Sprintf("OMDb returned HTTP %d", se.code)
+	}
+	respondError(c, 502, codeUpstream, msg)
+}
+
+// quotaResetsAt is when OMDb's daily limit is assumed to reset: the next
+// midnight UTC.
+func quotaResetsAt(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// respondQuotaExceeded writes a 429 telling the client to come back once
+// the daily quota has reset.
+func respondQuotaExceeded(c *gin.Context) {
+	wait := int(math.Ceil(time.Until(quotaResetsAt(time.Now())).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(wait))
+	respondErrorDetails(c, 429, codeQuotaExceeded, "OMDb daily request limit reached, try again tomorrow", gin.H{"retry_after_seconds": wait})
+}
+
+func respondOMDBError(c *gin.Context, msg, fallback string) {
+	if msg == "" {
+		msg = fallback
+	}
+	if isQuotaError(msg) {
+		respondQuotaExceeded(c)
+		return
+	}
+	status := omdbErrorStatus(msg)
+	code := codeNotFound
+	switch status {
+	case 400:
+		code = codeInvalidParam
+	case 502:
+		code = codeUpstream
+	}
+	respondError(c, status, code, msg)
+}
+
+type seedRef struct {
+	ID    string
+	Title string
+}
+
+// resolveSeed reads the movie an endpoint should start from. When both an id
+// and a title are given the id wins. favorite_movie is accepted as a title
+// for /api/recommend. It writes the 400 itself and returns false if neither
+// is present.
+func resolveSeed(c *gin.Context) (seedRef, bool) {
+	if id := c.Query("id"); id != "" {
+		return seedRef{ID: id}, true
+	}
+	t := c.Query("title")
+	if t == "" {
+		t = c.Query("favorite_movie")
+	}
+	if t == "" {
+		respondError(c, 400, codeMissingParam, "missing id or title")
+		return seedRef{}, false
+	}
+	return seedRef{Title: t}, true
+}
+
+func (s seedRef) params() map[string]string {
+	if s.ID != "" {
+		return map[string]string{"i": s.ID}
+	}
+	return map[string]string{"t": s.Title}
+}
+
+// movieHandler is GET /api/movie, a lookup by title or id. A title OMDb
+// has no exact match for falls back to the best search hit, flagged with
+// matchedExactly false.
+//
+//	@Summary	Look up a movie by title or imdbID
+//	@Tags	movies
+//	@Produce	json,xml
+//	@Param	title	query	string	false	"Movie title"
+//	@Param	id	query	string	false	"imdbID; wins over title"
+//	@Param	year	query	string	false	"Release year"
+//	@Param	plot	query	string	false	"Plot length, DEFAULT_PLOT if unset"	Enums(short, full)
+//	@Param	normalize	query	bool	false	"Return Ratings on a 0-100 scale"
+//	@Param	fields	query	string	false	"Comma separated fields to keep"
+//	@Success	200	{object}	movieLookupResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/movie [get]
+func movieHandler(c *gin.Context) {
+	seed, ok := resolveSeed(c)
+	if !ok {
+		return
+	}
+	plot, err := parsePlot(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	params := seed.params()
+	params["plot"] = plot
+	year := c.Query("year")
+	if year != "" {
+		params["y"] = year
+	}
+	ctx := c.Request.Context()
+	var m Movie
+	if err := fetchJSON(ctx, omdbURL(params), &m); err != nil {
+		respondFetchError(c, err, "movie not found")
+		return
+	}
+	resp := movieLookupResponse{MatchedExactly: true}
+	if !m.ok() && seed.Title != "" && omdbErrorStatus(m.Error) == 404 {
+		// No exact match: take the best search hit for the title instead,
+		// and offer the next few as alternatives.
+		hits, err := fuzzyMatches(ctx, seed.Title, year)
+		if err != nil {
+			respondFetchError(c, err, "movie not found")
+			return
+		}
+		if len(hits) > 0 {
+			m = Movie{}
+			if err := fetchJSON(ctx, omdbURL(map[string]string{"i": hits[0].ImdbID, "plot": plot}), &m); err != nil {
+				respondFetchError(c, err, "movie not found")
+				return
+			}
+			resp.MatchedExactly = false
+			for _, it := range hits[1:] {
+				resp.DidYouMean = append(resp.DidYouMean, titleRef{it.Title, it.Year, it.ImdbID})
+			}
+		}
+	}
+	if !m.ok() {
+		if year != "" && omdbErrorStatus(m.Error) == 404 {
+			respondError(c, 404, codeNotFound, "movie not found for year "+year)
+			return
+		}
+		respondOMDBError(c, m.Error, "movie not found")
+		return
+	}
+	resp.movieResponse = movieBody(c, &m)
+	if body, ok := selectFields(c, resp); ok {
+		respond(c, 200, body)
+	}
+}
+
+// maxDidYouMean is how many alternatives a fuzzy /api/movie match lists.
+const maxDidYouMean = 5
+
+// fuzzyMatches is the search fallback getDetailByTitle uses, for a title
+// with no exact match: the closest hit followed by up to maxDidYouMean
+// others, all from year when it is set. Series and episodes are skipped.
+func fuzzyMatches(ctx context.Context, title, year string) ([]searchItem, error) {
+	ranked, _, err := rankedHits(ctx, title, "")
+	hits := []searchItem{}
+	for _, it := range ranked {
+		if it.Type == "movie" && strings.HasPrefix(it.Year, year) && len(hits) <= maxDidYouMean {
+			hits = append(hits, it)
+		}
+	}
+	return hits, err
+}
+
+// defaultPlot is the plot length used when a request has no plot param,
+// set from DEFAULT_PLOT.
+var defaultPlot = "full"
+
+// parsePlot reads the plot param, short or full, defaulting to defaultPlot.
+func parsePlot(c *gin.Context) (string, error) {
+	switch v := c.DefaultQuery("plot", defaultPlot); v {
+	case "short", "full":
+		return v, nil
+	default:
+		return "", &paramError{"plot", v, "short or full"}
+	}
+}
+
+// parseType reads the type param, which is passed to OMDb's type= filter.
+// Empty means any type.
+func parseType(c *gin.Context) (string, error) {
+	switch v := c.Query("type"); v {
+	case "", "movie", "series", "episode":
+		return v, nil
+	default:
+		return "", &paramError{"type", v, "movie, series or episode"}
+	}
+}
+
+var imdbIDPattern = regexp.MustCompile(`^tt\d+$`)
+
+// movieByIDHandler is GET /api/movie/id/:imdbID, answered like movieHandler.
+//
+//	@Summary	Look up a movie by imdbID
+//	@Tags	movies
+//	@Produce	json,xml
+//	@Param	imdbID	path	string	true	"imdbID, e.g. tt1375666"
+//	@Param	plot	query	string	false	"Plot length, DEFAULT_PLOT if unset"	Enums(short, full)
+//	@Param	normalize	query	bool	false	"Return Ratings on a 0-100 scale"
+//	@Param	fields	query	string	false	"Comma separated fields to keep"
+//	@Success	200	{object}	movieResponse
+//	@Failure	400	{object}	errorResponse	"INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/movie/id/{imdbID} [get]
+func movieByIDHandler(c *gin.Context) {
+	id := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(id) {
+		respondParamError(c, &paramError{"imdbID", id, "tt followed by digits"})
+		return
+	}
+	plot, err := parsePlot(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	// The detail cache only holds short plots; a full one is fetched the
+	// way movieHandler does.
+	var m *Movie
+	if plot == "short" {
+		if m, err = getDetailByID(c.Request.Context(), id); err != nil {
+			respondFetchError(c, err, "movie not found")
+			return
+		}
+	} else {
+		m = &Movie{}
+		if err := fetchJSON(c.Request.Context(), omdbURL(map[string]string{"i": id, "plot": plot}), m); err != nil {
+			respondFetchError(c, err, "movie not found")
+			return
+		}
+		if !m.ok() {
+			respondOMDBError(c, m.Error, "movie not found")
+			return
+		}
+	}
+	if body, ok := selectFields(c, movieBody(c, m)); ok {
+		respond(c, 200, body)
+	}
+}
+
+// movieBody is the /api/movie response for m.
+func movieBody(c *gin.Context, m *Movie) movieResponse {
+	resp := movieResponse{
+		Title:          m.Title,
+		Year:           m.Year,
+		Rated:          nullableString(m.Rated),
+		Released:       nullableString(m.Released),
+		Writer:         nullableString(m.Writer),
+		Language:       nullableString(m.Language),
+		Production:     nullableString(m.Production),
+		Plot:           m.Plot,
+		Country:        m.Country,
+		Awards:         m.Awards,
+		Director:       m.Director,
+		Ratings:        m.Ratings,
+		Poster:         m.Poster,
+		Runtime:        nullableString(m.Runtime),
+		RuntimeMinutes: nullableInt(parseRuntime(m.Runtime)),
+		BoxOffice:      nullableString(m.BoxOffice),
+	}
+	resp.RottenTomatoes, resp.Metacritic = ratingScores(m.Ratings)
+	if n, ok := parseBoxOffice(m.BoxOffice); ok {
+		resp.BoxOfficeUSD = &n
+	}
+	if c.Query("normalize") == "true" {
+		resp.Ratings = normalizeRatings(m)
+	}
+	return resp
+}
+
+// parseRuntime turns OMDb's Runtime ("142 min", also "2 h 5 min") into minutes.
+// It returns 0 for "N/A", empty or unrecognized values.
+func parseRuntime(v string) int {
+	fields := strings.Fields(strings.ToLower(v))
+	total := 0
+	for i := 0; i < len(fields); i++ {
+		num := strings.TrimRightFunc(fields[i], unicode.IsLetter)
+		unit := fields[i][len(num):]
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 0 {
+			return 0
+		}
+		if unit == "" && i+1 < len(fields) {
+			if _, err := strconv.Atoi(fields[i+1]); err != nil {
+				unit = fields[i+1]
+				i++
+			}
+		}
+		switch unit {
+		case "h", "hr", "hrs", "hour", "hours":
+			total += n * 60
+		case "", "m", "min", "mins", "minute", "minutes":
+			total += n
+		default:
+			return 0
+		}
+	}
+	return total
+}
+
+// parseBoxOffice turns OMDb's BoxOffice ("$858,373,000") into dollars. The
+// "$" is optional; "N/A", empty and anything else non-numeric report false.
+func parseBoxOffice(v string) (int64, bool) {
+	v = strings.ReplaceAll(strings.TrimPrefix(strings.TrimSpace(v), "$"), ",", "")
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// nullableString maps OMDb's "N/A", and a missing value, to JSON null.
+func nullableString(v string) *string {
+	if v == "" || v == "N/A" {
+		return nil
+	}
+	return &v
+}
+
+// nullableInt maps the zero value helpers use for "unknown" to JSON null.
+func nullableInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+// ratingScores extracts the Rotten Tomatoes percent ("94%") and Metacritic
+// score ("88/100") from an OMDb Ratings array. Missing or malformed entries
+// come back as nil.
+func ratingScores(ratings []Rating) (rottenTomatoes, metacritic *int) {
+	for _, r := range ratings {
+		switch r.Source {
+		case "Rotten Tomatoes":
+			rottenTomatoes = parseScore(r.Value, "%")
+		case "Metacritic":
+			metacritic = parseScore(r.Value, "/100")
+		}
+	}
+	return rottenTomatoes, metacritic
+}
+
+func parseScore(v, suffix string) *int {
+	v = strings.TrimSpace(v)
+	if !strings.HasSuffix(v, suffix) {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(v, suffix))
+	if err != nil || n < 0 || n > 100 {
+		return nil
+	}
+	return &n
+}
+
+type normRating struct {
+	Source   string  `json:"source" xml:"source"`
+	Value    string  `json:"value" xml:"value"`
+	Scale100 float64 `json:"scale100" xml:"scale100"`
+}
+
+// scale100 converts OMDb rating strings such as "8.8/10", "94%" and "82/100"
+// onto a 0-100 scale.
+func scale100(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+	if strings.HasSuffix(v, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		return f, err == nil
+	}
+	num, den, ok := strings.Cut(v, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d <= 0 {
+		return 0, false
+	}
+	return math.Round(n/d*1000) / 10, true
+}
+
+// normalizeRatings returns every parseable rating of m on a common scale,
+// folding in imdbRating and Metascore when the Ratings array lacks them.
+func normalizeRatings(m *Movie) []normRating {
+	out := []normRating{}
+	seen := map[string]bool{}
+	for _, r := range m.Ratings {
+		if f, ok := scale100(r.Value); ok && r.Source != "" {
+			out = append(out, normRating{Source: r.Source, Value: r.Value, Scale100: f})
+			seen[r.Source] = true
+		}
+	}
+	extra := []struct{ raw, src, suffix string }{
+		{m.ImdbRating, "Internet Movie Database", "/10"},
+		{m.Metascore, "Metacritic", "/100"},
+	}
+	for _, e := range extra {
+		if seen[e.src] || e.raw == "" || e.raw == "N/A" {
+			continue
+		}
+		if f, ok := scale100(e.raw + e.suffix); ok {
+			out = append(out, normRating{Source: e.src, Value: e.raw + e.suffix, Scale100: f})
+		}
+	}
+	return out
+}
+
+// episodeHandler is GET /api/episode.
+//
+//	@Summary	Look up one episode of a series
+//	@Tags	series
+//	@Produce	json,xml
+//	@Param	series_title	query	string	true	"Series title"
+//	@Param	season	query	int	true	"Season number"
+//	@Param	episode_number	query	int	true	"Episode number"
+//	@Param	plot	query	string	false	"Plot length, DEFAULT_PLOT if unset"	Enums(short, full)
+//	@Param	fields	query	string	false	"Comma separated fields to keep"
+//	@Success	200	{object}	episodeResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/episode [get]
+func episodeHandler(c *gin.Context) {
+	s := c.Query("series_title")
+	if !requireParams(c, "series_title", "season", "episode_number") {
+		return
+	}
+	se, err := positiveInt(c, "season")
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	e, err := positiveInt(c, "episode_number")
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	plot, err := parsePlot(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	u := omdbURL(map[string]string{"t": s, "Season": se, "Episode": e, "plot": plot})
+	var m Movie
+	if err := fetchJSON(c.Request.Context(), u, &m); err != nil {
+		respondFetchError(c, err, "episode not found")
+		return
+	}
+	if !m.ok() {
+		respondOMDBError(c, m.Error, "episode not found")
+		return
+	}
+	body, ok := selectFields(c, episodeResponse{
+		Title:      m.Title,
+		Season:     m.Season,
+		Episode:    m.Episode,
+		Released:   m.Released,
+		Plot:       m.Plot,
+		ImdbRating: m.ImdbRating,
+		Poster:     m.Poster,
+	})
+	if ok {
+		respond(c, 200, body)
+	}
+}
+
+// seasonHandler is GET /api/season.
+//
+//	@Summary	List the episodes of a season
+//	@Tags	series
+//	@Produce	json,xml
+//	@Param	series_title	query	string	true	"Series title"
+//	@Param	season	query	int	true	"Season number"
+//	@Success	200	{object}	seasonResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/season [get]
+func seasonHandler(c *gin.Context) {
+	s := c.Query("series_title")
+	if !requireParams(c, "series_title", "season") {
+		return
+	}
+	se, err := positiveInt(c, "season")
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	respondSeason(c, s, se)
+}
+
+// respondSeason writes the episode list of season se of series s, in
+// episode order.
+func respondSeason(c *gin.Context, s, se string) {
+	u := omdbURL(map[string]string{"t": s, "Season": se})
+	var sr seasonResult
+	if err := fetchJSON(c.Request.Context(), u, &sr); err != nil {
+		respondFetchError(c, err, "season not found")
+		return
+	}
+	if !sr.ok() || len(sr.Episodes) == 0 {
+		respondOMDBError(c, sr.Error, "season not found")
+		return
+	}
+	eps := sr.Episodes
+	sort.SliceStable(eps, func(i, j int) bool {
+		a, _ := strconv.Atoi(eps[i].Episode)
+		b, _ := strconv.Atoi(eps[j].Episode)
+		return a < b
+	})
+	respond(c, 200, seasonResponse{Title: sr.Title, Season: sr.Season, TotalSeasons: sr.TotalSeasons, Count: len(eps), Episodes: eps})
+}
+
+// searchPage is one page of an OMDb search, narrowed to typ and year when
+// they are set.
+func searchPage(ctx context.Context, keyword string, page int, typ, year string) (searchResult, error) {
+	params := map[string]string{"s": keyword, "page": strconv.Itoa(page)}
+	if typ != "" {
+		params["type"] = typ
+	}
+	if year != "" {
+		params["y"] = year
+	}
+	var sr searchResult
+	err := fetchJSON(ctx, omdbURL(params), &sr)
+	return sr, err
+}
+
+// searchHandler is GET /api/search, one or more pages of OMDb results.
+//
+//	@Summary	Search titles
+//	@Tags	search
+//	@Produce	json,xml
+//	@Param	query	query	string	true	"Search text"
+//	@Param	page	query	int	false	"Page, from 1"
+//	@Param	depth	query	int	false	"Pages to read from page on, at most 5"
+//	@Param	type	query	string	false	"Restrict to a type"	Enums(movie, series, episode)
+//	@Param	sort	query	string	false	"Sort key; relevance order if unset"	Enums(rating, year, title)
+//	@Param	order	query	string	false	"Sort order"	Enums(asc, desc)
+//	@Success	200	{object}	searchResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/search [get]
+func searchHandler(c *gin.Context) {
+	q := c.Query("query")
+	if !requireParams(c, "query") {
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	// Search results keep OMDb's relevance order unless sort is given.
+	var rank ranking
+	sortBy, order := "", ""
+	if c.Query("sort") != "" {
+		if rank, sortBy, order, err = parseSort(c); err != nil {
+			respondParamError(c, err)
+			return
+		}
+	}
+	depth := 1
+	if d, err := strconv.Atoi(c.Query("depth")); err == nil && d > 0 {
+		depth = min(d, maxSearchDepth)
+	}
+	ctx := c.Request.Context()
+	sr, err := searchPage(ctx, q, page, typ, "")
+	if err != nil {
+		respondFetchError(c, err, "no results")
+		return
+	}
+	if !sr.ok() && page > 1 && omdbErrorStatus(sr.Error) == 404 {
+		// OMDb reports a page past the end as not found. Check page 1 so
+		// that case reads as an empty page rather than an unknown query.
+		first, err := searchPage(ctx, q, 1, typ, "")
+		if err == nil && first.ok() {
+			total, _ := strconv.Atoi(first.TotalResults)
+			respond(c, 200, searchResponse{Query: q, Page: page, Depth: depth, TotalResults: total, TotalPages: pageCount(total), Results: []searchItem{}})
+			return
+		}
+	}
+	if !sr.ok() {
+		respondOMDBError(c, sr.Error, "no results")
+		return
+	}
+	total, _ := strconv.Atoi(sr.TotalResults)
+	pages := [][]searchItem{sr.Search}
+	last := page
+	for p := page + 1; p < page+depth && p <= pageCount(total); p++ {
+		more, err := searchPage(ctx, q, p, typ, "")
+		if err != nil || !more.ok() {
+			break
+		}
+		pages = append(pages, more.Search)
+		last = p
+	}
+	results := dedupSearchItems(pages...)
+	addDetails(ctx, results, sortBy == "rating")
+	body := searchResponse{
+		Query:        q,
+		Page:         page,
+		Depth:        depth,
+		TotalResults: total,
+		TotalPages:   pageCount(total),
+		HasMore:      last < pageCount(total),
+		Count:        len(results),
+		Results:      results,
+	}
+	if rank != nil {
+		sort.SliceStable(results, func(i, j int) bool { return rank(results[i].movie(), results[j].movie()) })
+		body.Sort, body.Order = sortBy, order
+	}
+	respond(c, 200, body)
+}
+
+// addDetails looks up totalSeasons for the series among items and, with
+// ratings set, imdbRating for every item. Otherwise movies and episodes cost
+// nothing extra.
+func addDetails(ctx context.Context, items []searchItem, ratings bool) {
+	sem := make(chan struct{}, enrichWorkers)
+	var wg sync.WaitGroup
+	for i := range items {
+		if (!ratings && items[i].Type != "series") || items[i].ImdbID == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(it *searchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			md, err := getDetailByID(ctx, it.ImdbID)
+			if err != nil {
+				return
+			}
+			if it.Type == "series" && md.TotalSeasons != "N/A" {
+				it.TotalSeasons = md.TotalSeasons
+			}
+			if ratings {
+				it.ImdbRating = md.ImdbRating
+			}
+		}(&items[i])
+	}
+	wg.Wait()
+}
+
+// maxSearchDepth caps how many consecutive pages one /api/search may read.
+const maxSearchDepth = 5
+
+// dedupSearchItems concatenates pages, dropping repeated imdbIDs. OMDb can
+// return the same title on neighbouring pages; the first occurrence wins.
+func dedupSearchItems(pages ...[]searchItem) []searchItem {
+	seen := map[string]bool{}
+	out := []searchItem{}
+	for _, items := range pages {
+		for _, it := range items {
+			if it.ImdbID != "" && seen[it.ImdbID] {
+				continue
+			}
+			seen[it.ImdbID] = true
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+func getDetailByID(ctx context.Context, id string) (*Movie, error) {
+	if m, ok := details.get(idKey(id)); ok {
+		if m == nil {
+			return nil, errNotFound
+		}
+		return m, nil
+	}
+	return shared(ctx, idKey(id), func(ctx context.Context) (*Movie, error) { return fetchDetailByID(ctx, id) })
+}
+
+func fetchDetailByID(ctx context.Context, id string) (*Movie, error) {
+	u := omdbURL(map[string]string{"i": id, "plot": "short"})
+	var md Movie
+	if err := fetchJSON(ctx, u, &md); err != nil {
+		return nil, err
+	}
+	if !md.ok() {
+		if omdbErrorStatus(md.Error) == 404 {
+			details.putMissing(idKey(id))
+		}
+		return nil, omdbError(md.Error)
+	}
+	details.put(&md, idKey(id))
+	return &md, nil
+}
+
+// getDetailByTitle tries an exact title match, then the closest hit from
+// the first two pages of a search for it (see rankedHits), both restricted
+// to typ when set. The title is only cached as missing when every one of
+// those OMDb answers was a real miss rather than an error.
+func getDetailByTitle(ctx context.Context, title, typ string) (*Movie, error) {
+	key := titleKey(title, typ)
+	if m, ok := details.get(key); ok {
+		if m == nil {
+			return nil, errNotFound
+		}
+		return m, nil
+	}
+	return shared(ctx, key, func(ctx context.Context) (*Movie, error) { return fetchDetailByTitle(ctx, title, typ, key) })
+}
+
+func fetchDetailByTitle(ctx context.Context, title, typ, key string) (*Movie, error) {
+	params := map[string]string{"t": title, "plot": "short"}
+	if typ != "" {
+		params["type"] = typ
+	}
+	var md Movie
+	definite := true
+	if err := fetchJSON(ctx, omdbURL(params), &md); err == nil {
+		if md.ok() {
+			details.put(&md, key, idKey(md.ImdbID))
+			return &md, nil
+		}
+		if isQuotaError(md.Error) {
+			return nil, errQuotaExceeded
+		}
+		definite = omdbErrorStatus(md.Error) == 404
+	} else if errors.Is(err, errRateLimited) || errors.Is(err, errQuotaExceeded) || errors.Is(err, errCircuitOpen) {
+		return nil, err
+	} else {
+		definite = false
+	}
+	hits, searched, err := rankedHits(ctx, title, typ)
+	if err != nil {
+		return nil, err
+	}
+	var found *Movie
+	for _, it := range hits {
+		m, err := getDetailByID(ctx, it.ImdbID)
+		if err == nil {
+			found = m
+			break
+		}
+		if errors.Is(err, errQuotaExceeded) {
+			return nil, err
+		}
+		if !errors.Is(err, errNotFound) {
+			definite = false
+		}
+	}
+	switch {
+	case found != nil:
+		details.put(found, key)
+		return found, nil
+	case definite && searched:
+		details.putMissing(key)
+	}
+	return nil, errNotFound
+}
+
+// matchTitle is getDetailByTitle along with how closely the movie's title
+// matches the one asked for, by titleSimilarity. The score depends only on
+// the two titles, so a cached answer scores the same as a fresh one.
+func matchTitle(ctx context.Context, title, typ string) (*Movie, float64, error) {
+	m, err := getDetailByTitle(ctx, title, typ)
+	if err != nil {
+		return nil, 0, err
+	}
+	return m, titleSimilarity(title, m.Title), nil
+}
+
+// rankedHits is every searchTitle hit scoring at least minTitleScore
+// against title, best first and in OMDb's order among equals.
+func rankedHits(ctx context.Context, title, typ string) ([]searchItem, bool, error) {
+	var hits []searchItem
+	score := map[string]float64{}
+	definite, err := searchTitle(ctx, title, typ, func(it searchItem) bool {
+		if s := titleSimilarity(title, it.Title); s >= minTitleScore && score[it.ImdbID] == 0 {
+			score[it.ImdbID] = s
+			hits = append(hits, it)
+		}
+		return true
+	})
+	sort.SliceStable(hits, func(i, j int) bool { return score[hits[i].ImdbID] > score[hits[j].ImdbID] })
+	return hits, definite, err
+}
+
+// titleSearchPages is how deep a title lookup searches once the exact
+// match has missed.
+const titleSearchPages = 2
+
+// searchTitle is the fallback for a title the exact t= lookup missed. It
+// reads up to titleSearchPages of a search for title, restricted to typ when
+// set, and calls visit with
+// each hit, in OMDb's relevance order, until visit returns false. definite
+// is false if a page failed rather than answering; only errQuotaExceeded is
+// returned as an error.
+func searchTitle(ctx context.Context, title, typ string, visit func(searchItem) bool) (definite bool, err error) {
+	for p := 1; p <= titleSearchPages; p++ {
+		sr, err := searchPage(ctx, title, p, typ, "")
+		if errors.Is(err, errQuotaExceeded) || isQuotaError(sr.Error) {
+			return false, errQuotaExceeded
+		}
+		if err != nil || !sr.ok() {
+			return err == nil && omdbErrorStatus(sr.Error) == 404, nil
+		}
+		for _, it := range sr.Search {
+			if it.ImdbID != "" && !visit(it) {
+				return true, nil
+			}
+		}
+		if lastPage(len(sr.Search)) {
+			break
+		}
+	}
+	return true, nil
+}
+
+func ratingVal(m *Movie) float64 {
+	if r := m.ImdbRating; r != "N/A" && r != "" {
+		if f, err := strconv.ParseFloat(r, 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+// A genre crawl stops after genreCrawlLimit matches, so that also bounds
+// total and how far offset can page.
+const genreCrawlLimit = 150
+const defaultGenreLimit = 15
+const maxGenreLimit = 100
+
+// moviesByGenreHandler is GET /api/movies/genre.
+//
+//	@Summary	Top movies of a genre
+//	@Description	OMDb has no genre search, so this crawls seed keywords; see crawl.go.
+//	@Tags	movies
+//	@Produce	json,xml
+//	@Param	genre	query	string	true	"Genre, or comma separated genres, e.g. action,sci-fi"
+//	@Param	mode	query	string	false	"Whether a movie needs all the genres or any"	Enums(any, all)	default(any)
+//	@Param	limit	query	int	false	"Movies to return, at most 100"	default(15)
+//	@Param	offset	query	int	false	"Movies to skip"
+//	@Param	page	query	int	false	"Page of page_size movies, from 1; instead of offset"
+//	@Param	page_size	query	int	false	"Same as limit"
+//	@Param	sort	query	string	false	"Sort key"	Enums(rating, year, title)
+//	@Param	order	query	string	false	"Sort order"	Enums(asc, desc)
+//	@Param	missing	query	string	false	"Where unrated movies sort"	Enums(last, first)
+//	@Param	complete_only	query	bool	false	"Only movies with poster, plot, rating and genre"
+//	@Param	year_min	query	int	false	"Earliest year"
+//	@Param	year_max	query	int	false	"Latest year"
+//	@Param	min_rating	query	number	false	"Lowest imdbRating, 0-10"
+//	@Param	max_rating	query	number	false	"Highest imdbRating, 0-10"
+//	@Param	rating_min	query	number	false	"Same as min_rating"
+//	@Param	rating_max	query	number	false	"Same as max_rating"
+//	@Param	max_requests	query	int	false	"Cap on OMDb requests for the crawl"
+//	@Param	type	query	string	false	"Restrict to a type"	Enums(movie, series, episode)
+//	@Param	debug	query	bool	false	"Include crawl diagnostics"
+//	@Success	200	{object}	genreResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	429	{object}	errorResponse	"QUOTA_EXCEEDED"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/movies/genre [get]
+func moviesByGenreHandler(c *gin.Context) {
+	genre := c.Query("genre")
+	if !requireParams(c, "genre") {
+		return
+	}
+	genres := splitList(genre)
+	if len(genres) == 0 {
+		respondParamError(c, &paramError{"genre", genre, "one or more comma separated genres"})
+		return
+	}
+	mode := c.DefaultQuery("mode", "any")
+	if mode != "any" && mode != "all" {
+		respondParamError(c, &paramError{"mode", mode, "all or any"})
+		return
+	}
+	keep, applied, err := parseFilters(c, genreFilterRules)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	rank, sortBy, order, err := parseSort(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	// page_size is another name for limit, and page another way to give
+	// offset, in units of the page size.
+	limit := defaultGenreLimit
+	for _, p := range []string{"limit", "page_size"} {
+		if v := c.Query(p); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				respondParamError(c, &paramError{p, v, "a positive integer"})
+				return
+			}
+			limit = min(n, maxGenreLimit)
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondParamError(c, &paramError{"offset", v, "a non-negative integer"})
+			return
+		}
+		offset = n
+	}
+	if c.Query("page") != "" {
+		if c.Query("offset") != "" {
+			respondError(c, 400, codeInvalidParam, "use offset or page, not both")
+			return
+		}
+		page, err := parsePage(c)
+		if err != nil {
+			respondParamError(c, err)
+			return
+		}
+		offset = (page - 1) * limit
+	}
+	var stats collectStats
+	budget := 0
+	if v := c.Query("max_requests"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondParamError(c, &paramError{"max_requests", v, "a positive integer"})
+			return
+		}
+		budget = n
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	// The whole ranked crawl is cached so later pages don't crawl again. A
+	// crawl cut short by the deadline or the quota isn't.
+	key := genreListKey(c.Request.URL.Query())
+	var top []*Movie
+	if l, ok := genreLists.get(key); ok {
+		top, stats = l.movies, l.stats
+	} else {
+		ctx := c.Request.Context()
+		top = collectTopByGenre(ctx, genre, genreCrawlLimit, crawlOpts{limit: genreCrawlLimit, keep: keep, rank: rank, typ: typ, all: mode == "all", budget: budget, stats: &stats})
+		if timedOut(c) {
+			return
+		}
+		if stats.stopped != nil && len(top) == 0 {
+			respondFetchError(c, stats.stopped, "")
+			return
+		}
+		if ctx.Err() == nil && stats.stopped == nil {
+			genreLists.put(key, top, stats)
+		}
+	}
+	top = top[min(offset, len(top)):]
+	top = top[:min(limit, len(top))]
+	out := make([]movieSummary, 0, len(top))
+	for _, m := range top {
+		out = append(out, summarize(m))
+	}
+	body := genreResponse{
+		Genre:      genre,
+		Genres:     genres,
+		Mode:       mode,
+		Type:       typ,
+		Filters:    applied,
+		Sort:       sortBy,
+		Order:      order,
+		Offset:     offset,
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		Count:      len(out),
+		Total:      stats.kept(),
+		TotalPages: (stats.kept() + limit - 1) / limit,
+		Movies:     out,
+	}
+	if c.Query("debug") == "true" {
+		body.Diagnostics = &stats
+	}
+	respond(c, 200, body)
+}
+
+type reasonCode string
+
+const (
+	reasonGenreMatch      reasonCode = "GENRE_MATCH"
+	reasonSameDirector    reasonCode = "SAME_DIRECTOR"
+	reasonSharedActor     reasonCode = "SHARED_ACTOR"
+	reasonPopularFallback reasonCode = "POPULAR_FALLBACK"
+)
+
+type recommendation struct {
+	movie   *Movie
+	code    reasonCode
+	matched string
+}
+
+func (r recommendation) reason() string {
+	switch r.code {
+	case reasonGenreMatch:
+		return "Also a " + r.matched + " title"
+	case reasonSameDirector:
+		return "Directed by " + r.matched
+	case reasonSharedActor:
+		return "Also stars " + r.matched
+	}
+	return "Popular pick"
+}
+
+// recommendHandler is GET /api/recommend. Candidates come from the seed's
+// genres, then its director, then its actors, then popular titles.
+//
+//	@Summary	Recommend movies like a favorite
+//	@Tags	movies
+//	@Produce	json,xml
+//	@Param	title	query	string	false	"Favorite movie title (or favorite_movie)"
+//	@Param	id	query	string	false	"Favorite movie imdbID"
+//	@Param	exclude	query	string	false	"Comma separated imdbIDs or titles to leave out"
+//	@Param	missing	query	string	false	"Where unrated movies sort"	Enums(last, first)
+//	@Param	complete_only	query	bool	false	"Only movies with poster, plot, rating and genre"
+//	@Param	min_rating	query	number	false	"Lowest imdbRating"
+//	@Param	year_min	query	int	false	"Earliest year"
+//	@Param	year_max	query	int	false	"Latest year"
+//	@Param	genres	query	string	false	"Comma separated genres, any of which must match"
+//	@Param	exclude_genres	query	string	false	"Comma separated genres to leave out"
+//	@Param	exclude_ids	query	string	false	"Comma separated imdbIDs to leave out"
+//	@Param	type	query	string	false	"Restrict the favorite and recommendations to a type"	Enums(movie, series, episode)
+//	@Success	200	{object}	recommendResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/recommend [get]
+func recommendHandler(c *gin.Context) {
+	ref, ok := resolveSeed(c)
+	if !ok {
+		return
+	}
+	keep, _, err := parseFilters(c, recommendFilterRules)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	missing, err := parseMissing(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	rank := byRating(missing)
+	ctx := c.Request.Context()
+	var seed *Movie
+	var match *seedMatch
+	if ref.ID != "" {
+		seed, err = getDetailByID(ctx, ref.ID)
+	} else {
+		var score float64
+		if seed, score, err = matchTitle(ctx, ref.Title, typ); err == nil {
+			match = &seedMatch{Query: ref.Title, Title: seed.Title, ImdbID: seed.ImdbID, Score: score}
+		}
+	}
+	if err != nil {
+		respondFetchError(c, err, "favorite movie not found")
+		return
+	}
+	if typ != "" && seed.Type != typ {
+		respondError(c, 404, codeNotFound, seed.Title+" is not a "+typ)
+		return
+	}
+	perLevel := 20
+	// seen starts with the seed and anything in exclude, which takes imdbIDs
+	// and titles. Titles, like the seed's own, also drop their sequels.
+	seen := map[string]bool{}
+	if seed.ImdbID != "" {
+		seen[seed.ImdbID] = true
+	}
+	bases := []string{baseTitle(seed.Title)}
+	for _, v := range strings.Split(c.Query("exclude"), ",") {
+		if v = strings.TrimSpace(v); imdbIDPattern.MatchString(v) {
+			seen[v] = true
+		} else if v != "" {
+			bases = append(bases, baseTitle(v))
+		}
+	}
+	// The crawls below run concurrently and consult seen through excluded
+	// while add writes it, so both go through mu.
+	var mu sync.Mutex
+	excluded := func(m *Movie) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if seen[m.ImdbID] {
+			return true
+		}
+		for _, b := range bases {
+			if sameFranchise(b, m.Title) {
+				return true
+			}
+		}
+		return false
+	}
+	result := []recommendation{}
+	add := func(cands []*Movie, code reasonCode, matched string) {
+		for _, m := range cands {
+			if len(result) >= perLevel {
+				return
+			}
+			if m.ImdbID != "" && !excluded(m) {
+				mu.Lock()
+				seen[m.ImdbID] = true
+				mu.Unlock()
+				result = append(result, recommendation{movie: m, code: code, matched: matched})
+			}
+		}
+	}
+	// Each crawl skips movies already taken, so a crawl that starts or
+	// finishes later doesn't spend requests rediscovering the same titles.
+	fresh := func(m *Movie) bool { return !excluded(m) && (keep == nil || keep(m)) }
+	opts := func() crawlOpts { return crawlOpts{limit: perLevel, keep: fresh, rank: rank, typ: typ} }
+	crawlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	collect := func(t recommendTask) []*Movie {
+		if t.field == nil {
+			return collectTopByGenre(crawlCtx, t.value, perLevel, opts())
+		}
+		return collectTopByPerson(crawlCtx, t.value, t.field, perLevel, opts())
+	}
+	tasks := recommendTasks(seed)
+	// Every genre, director and actor crawl starts at once. Results are
+	// merged in the order the sequential levels would have produced them, as
+	// soon as everything ahead of them is in, so the reasons keep their
+	// priority and no title is listed twice. Which titles a crawl finds
+	// still depends on timing: fresh skips whatever has been merged so far,
+	// and a crawl that reads seen later skips more. Once perLevel are taken
+	// the rest are cancelled.
+	type crawlResult struct {
+		i      int
+		movies []*Movie
+	}
+	ch := make(chan crawlResult, len(tasks))
+	for i, t := range tasks {
+		go func() { ch <- crawlResult{i, collect(t)} }()
+	}
+	got := make([][]*Movie, len(tasks))
+	done := make([]bool, len(tasks))
+	next := 0
+	for n := 0; n < len(tasks) && len(result) < perLevel; n++ {
+		r := <-ch
+		got[r.i], done[r.i] = r.movies, true
+		for ; next < len(tasks) && done[next] && len(result) < perLevel; next++ {
+			add(got[next], tasks[next].code, tasks[next].value)
+		}
+	}
+	cancel()
+	if len(result) < perLevel {
+		add(collectTopByGenre(ctx, "", perLevel, opts()), reasonPopularFallback, "")
+	}
+	movies := make([]*Movie, len(result))
+	for i, r := range result {
+		movies[i] = r.movie
+	}
+	enrichMissing(ctx, movies, perLevel)
+	if timedOut(c) {
+		return
+	}
+	out := make([]recommendItem, 0, len(result))
+	for _, r := range result {
+		m := r.movie
+		it := recommendItem{
+			movieSummary: summarize(m),
+			Director:     m.Director,
+			Actors:       m.Actors,
+			Poster:       m.Poster,
+			Reason:       r.reason(),
+			ReasonCode:   r.code,
+			Matched:      r.matched,
+		}
+		it.RottenTomatoes, it.Metacritic = ratingScores(m.Ratings)
+		out = append(out, it)
+	}
+	respond(c, 200, recommendResponse{FavoriteMovie: seed.Title, SeedMatch: match, Recommendations: out})
+}
+
+// creditList splits a comma separated OMDb list such as Genre or Actors,
+// dropping blanks and "N/A".
+func creditList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" && p != "N/A" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// recommendTask is one crawl of /api/recommend: a genre when field is nil,
+// otherwise a person credited in field.
+type recommendTask struct {
+	code  reasonCode
+	value string
+	field func(*Movie) string
+}
+
+// recommendTasks lists one crawl per genre, director and actor of seed, in
+// that order.
+func recommendTasks(seed *Movie) []recommendTask {
+	levels := []struct {
+		values string
+		code   reasonCode
+		field  func(*Movie) string
+	}{
+		{seed.Genre, reasonGenreMatch, nil},
+		{seed.Director, reasonSameDirector, func(m *Movie) string { return m.Director }},
+		{seed.Actors, reasonSharedActor, func(m *Movie) string { return m.Actors }},
+	}
+	var tasks []recommendTask
+	for _, lv := range levels {
+		for _, v := range creditList(lv.values) {
+			tasks = append(tasks, recommendTask{lv.code, v, lv.field})
+		}
+	}
+	return tasks
+}
+
+// enrichWorkers caps the detail lookups one request runs at once to fill in
+// a list: addDetails, the recommend pass in enrichMissing and the watchlist.
+const enrichWorkers = 4
+
+// missingFields reports whether m lacks any field a recommendation shows.
+func missingFields(m *Movie) bool {
+	return m.Title == "" || m.Year == "" || m.Genre == "" || m.Director == "" || m.Actors == "" || m.ImdbRating == "" || m.Poster == ""
+}
+
+// fillMissing copies the fields missingFields checks from src where m has none.
+func (m *Movie) fillMissing(src *Movie) {
+	for _, f := range []struct {
+		dst *string
+		v   string
+	}{
+		{&m.Title, src.Title}, {&m.Year, src.Year}, {&m.Genre, src.Genre},
+		{&m.Director, src.Director}, {&m.Actors, src.Actors}, {&m.ImdbRating, src.ImdbRating},
+		{&m.Poster, src.Poster},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.v
+		}
+	}
+}
+
+// enrichMissing re-fetches details for movies in list that lack any shown
+// field, at most budget of them and enrichWorkers at a time, and fills the
+// gaps in place.
+func enrichMissing(ctx context.Context, list []*Movie, budget int) {
+	sem := make(chan struct{}, enrichWorkers)
+	var wg sync.WaitGroup
+	for _, m := range list {
+		if budget <= 0 || ctx.Err() != nil {
+			break
+		}
+		if m.ImdbID == "" || !missingFields(m) {
+			continue
+		}
+		budget--
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(m *Movie) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if md, err := getDetailByID(ctx, m.ImdbID); err == nil {
+				m.fillMissing(md)
+			}
+		}(m)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// roundTripFunc stubs httpClient's transport.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func stubTransport(t *testing.T, rt roundTripFunc) {
+	t.Helper()
+	old, oldDelay, oldBreaker := httpClient.Transport, retryBaseDelay, omdbBreaker
+	httpClient.Transport, retryBaseDelay = rt, time.Millisecond
+	omdbBreaker = &breaker{threshold: oldBreaker.threshold, cooldown: oldBreaker.cooldown}
+	t.Cleanup(func() { httpClient.Transport, retryBaseDelay, omdbBreaker = old, oldDelay, oldBreaker })
+}
+
+func reply(code int, body string) *http.Response {
+	return &http.Response{StatusCode: code, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestFetchJSONRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     func() (*http.Response, error)
+		failures int
+		calls    int
+		wantErr  bool
+	}{
+		{"502 twice then ok", func() (*http.Response, error) { return reply(502, ""), nil }, 2, 3, false},
+		{"429 twice then ok", func() (*http.Response, error) { return reply(429, ""), nil }, 2, 3, false},
+		{"network error twice then ok", func() (*http.Response, error) { return nil, errors.New("connection reset") }, 2, 3, false},
+		{"502 every time", func() (*http.Response, error) { return reply(502, ""), nil }, 5, 3, true},
+		{"404 is not retried", func() (*http.Response, error) { return reply(404, ""), nil }, 5, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			stubTransport(t, func(*http.Request) (*http.Response, error) {
+				if calls++; calls <= tt.failures {
+					return tt.fail()
+				}
+				return reply(200, `{"Title":"Inception","Response":"True"}`), nil
+			})
+			var m Movie
+			err := fetchJSON(context.Background(), "http://omdb.test/?apikey=k&i=tt1375666", &m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if calls != tt.calls {
+				t.Errorf("%d calls, want %d", calls, tt.calls)
+			}
+			if !tt.wantErr && m.Title != "Inception" {
+				t.Errorf("Title = %q", m.Title)
+			}
+		})
+	}
+}
+
+func TestRetryDelayHonorsRetryAfter(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+	if d := retryDelay(1, &statusError{code: 429, retryAfter: 2 * time.Second}); d != 2*time.Second {
+		t.Errorf("Retry-After 2s gave %v", d)
+	}
+	if d := retryDelay(1, &statusError{code: 429, retryAfter: time.Minute}); d != maxRetryAfter {
+		t.Errorf("Retry-After 1m gave %v, want the %v cap", d, maxRetryAfter)
+	}
+	if d := retryDelay(1, &statusError{code: 502}); d >= time.Second {
+		t.Errorf("no Retry-After gave %v", d)
+	}
+}
+
+func TestEnrichMissing(t *testing.T) {
+	var mu sync.Mutex
+	fetched := map[string]int{}
+	stubTransport(t, func(r *http.Request) (*http.Response, error) {
+		id := r.URL.Query().Get("i")
+		mu.Lock()
+		fetched[id]++
+		mu.Unlock()
+		return reply(200, `{"Title":"Fetched","Year":"2001","Genre":"Drama","Director":"D","Actors":"A","imdbRating":"7.5","Poster":"https://img/`+id+`.jpg","imdbID":"`+id+`","Response":"True"}`), nil
+	})
+	full := &Movie{Title: "Full", Year: "1999", Genre: "Drama", Director: "D", Actors: "A", ImdbRating: "8.0", Poster: "p1", ImdbID: "tt1"}
+	noGenre := &Movie{Title: "Kept", Year: "2000", Director: "D", Actors: "A", ImdbRating: "6.0", Poster: "p2", ImdbID: "tt2"}
+	noRating := &Movie{Title: "No rating", Year: "2002", Genre: "Drama", Director: "D", Actors: "A", Poster: "p3", ImdbID: "tt3"}
+	noPoster := &Movie{Title: "No poster", Year: "2003", Genre: "Drama", Director: "D", Actors: "A", ImdbRating: "5.0", ImdbID: "tt4"}
+	noID := &Movie{Title: "No ID"}
+	list := []*Movie{full, noGenre, noRating, noPoster, noID}
+
+	// The budget counts fetches, not list entries.
+	enrichMissing(context.Background(), list, 1)
+	if fetched["tt1"] != 0 || fetched["tt2"] != 1 || fetched["tt3"] != 0 || len(fetched) != 1 {
+		t.Fatalf("budget 1 fetched %v, want only tt2", fetched)
+	}
+	if noGenre.Genre != "Drama" || noGenre.Title != "Kept" || noGenre.Poster != "p2" {
+		t.Errorf("tt2 = %+v, want Genre filled and Title and Poster kept", noGenre)
+	}
+	if noRating.ImdbRating != "" {
+		t.Errorf("tt3 filled past the budget: %+v", noRating)
+	}
+
+	enrichMissing(context.Background(), list, 10)
+	if fetched["tt2"] != 1 || fetched["tt3"] != 1 || fetched["tt4"] != 1 || len(fetched) != 3 {
+		t.Errorf("second pass fetched %v, want tt3 and tt4 once more", fetched)
+	}
+	if noRating.ImdbRating != "7.5" {
+		t.Errorf("tt3 = %+v, want imdbRating filled", noRating)
+	}
+	if noPoster.Poster != "https://img/tt4.jpg" || noPoster.ImdbRating != "5.0" {
+		t.Errorf("tt4 = %+v, want Poster filled and imdbRating kept", noPoster)
+	}
+}
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"142 min", 142},
+		{"N/A", 0},
+		{"", 0},
+		{"1 h 30 min", 90},
+		{"2h 5min", 125},
+		{"90", 90},
+		{"about an hour", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRuntime(tt.in); got != tt.want {
+			t.Errorf("parseRuntime(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func intPtrString(p *int) string {
+	if p == nil {
+		return "nil"
+	}
+	return strconv.Itoa(*p)
+}
+
+func TestRatingScores(t *testing.T) {
+	tests := []struct {
+		name     string
+		ratings  []Rating
+		rt, meta string
+	}{
+		{"both", []Rating{{"Internet Movie Database", "8.8/10"}, {"Rotten Tomatoes", "87%"}, {"Metacritic", "74/100"}}, "87", "74"},
+		{"no sources", nil, "nil", "nil"},
+		{"imdb only", []Rating{{"Internet Movie Database", "8.8/10"}}, "nil", "nil"},
+		{"rotten tomatoes only", []Rating{{"Rotten Tomatoes", " 100% "}}, "100", "nil"},
+		{"wrong suffixes", []Rating{{"Rotten Tomatoes", "87/100"}, {"Metacritic", "74%"}}, "nil", "nil"},
+		{"not numbers", []Rating{{"Rotten Tomatoes", "N/A"}, {"Metacritic", "x/100"}}, "nil", "nil"},
+		{"out of range", []Rating{{"Rotten Tomatoes", "101%"}, {"Metacritic", "-1/100"}}, "nil", "nil"},
+	}
+	for _, tt := range tests {
+		rt, meta := ratingScores(tt.ratings)
+		if got := intPtrString(rt); got != tt.rt {
+			t.Errorf("%s: RottenTomatoes = %s, want %s", tt.name, got, tt.rt)
+		}
+		if got := intPtrString(meta); got != tt.meta {
+			t.Errorf("%s: Metacritic = %s, want %s", tt.name, got, tt.meta)
+		}
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		v, suffix, want string
+	}{
+		{"94%", "%", "94"},
+		{"0%", "%", "0"},
+		{"88/100", "/100", "88"},
+		{"", "%", "nil"},
+		{"%", "%", "nil"},
+		{"9.4%", "%", "nil"},
+		{"88", "/100", "nil"},
+	}
+	for _, tt := range tests {
+		if got := intPtrString(parseScore(tt.v, tt.suffix)); got != tt.want {
+			t.Errorf("parseScore(%q, %q) = %s, want %s", tt.v, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestDedupSearchItems(t *testing.T) {
+	page1 := []searchItem{{Title: "Alien", ImdbID: "tt0078748"}, {Title: "Aliens", ImdbID: "tt0090605"}}
+	page2 := []searchItem{{Title: "Aliens (again)", ImdbID: "tt0090605"}, {Title: "Alien 3", ImdbID: "tt0103644"}}
+	page3 := []searchItem{{Title: "No ID"}, {Title: "No ID either"}, {Title: "Alien 3 (again)", ImdbID: "tt0103644"}}
+	got := dedupSearchItems(page1, page2, page3)
+	want := []string{"Alien", "Aliens", "Alien 3", "No ID", "No ID either"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Title != w {
+			t.Errorf("[%d] = %q, want %q", i, got[i].Title, w)
+		}
+	}
+	if got := dedupSearchItems(); len(got) != 0 {
+		t.Errorf("no pages gave %d items", len(got))
+	}
+}
+
+func TestMovieHandler(t *testing.T) {
+	startFakeOMDb(t, newFakeCatalog())
+	var found movieLookupResponse
+	if code := get(t, "/api/movie?title=inception", &found); code != 200 {
+		t.Fatalf("status %d", code)
+	}
+	if found.Title != "Inception" || found.Director != "Christopher Nolan" || !found.MatchedExactly {
+		t.Errorf("got %+v", found)
+	}
+	if found.Runtime == nil || *found.Runtime != "148 min" || found.RuntimeMinutes == nil || *found.RuntimeMinutes != 148 {
+		t.Errorf("Runtime = %v, RuntimeMinutes = %v", found.Runtime, found.RuntimeMinutes)
+	}
+	if found.BoxOffice != nil || found.BoxOfficeUSD != nil || found.Rated != nil {
+		t.Errorf("BoxOffice = %v, BoxOfficeUSD = %v, Rated = %v, want null for N/A", found.BoxOffice, found.BoxOfficeUSD, found.Rated)
+	}
+	var missing errorResponse
+	if code := get(t, "/api/movie?title=No+Such+Movie", &missing); code != 404 {
+		t.Fatalf("status %d", code)
+	}
+	if missing.Error.Code != codeNotFound {
+		t.Errorf("code = %s", missing.Error.Code)
+	}
+}
+
+func TestEpisodeHandler(t *testing.T) {
+	startFakeOMDb(t, newFakeCatalog())
+	tests := []struct {
+		query string
+		code  int
+		err   errCode
+	}{
+		{"series_title=Breaking+Bad&season=1&episode_number=1", 200, ""},
+		{"season=1&episode_number=1", 400, codeMissingParam},
+		{"series_title=Breaking+Bad&season=1", 400, codeMissingParam},
+		{"series_title=Breaking+Bad&episode_number=1", 400, codeMissingParam},
+		{"series_title=Breaking+Bad&season=0&episode_number=1", 400, codeInvalidParam},
+		{"series_title=Breaking+Bad&season=1&episode_number=x", 400, codeInvalidParam},
+		{"series_title=Breaking+Bad&season=1&episode_number=1&plot=long", 400, codeInvalidParam},
+		{"series_title=Breaking+Bad&season=9&episode_number=1", 404, codeNotFound},
+	}
+	for _, tt := range tests {
+		var body struct {
+			episodeResponse
+			errorResponse
+		}
+		if code := get(t, "/api/episode?"+tt.query, &body); code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.query, code, tt.code)
+		}
+		if body.Error.Code != tt.err {
+			t.Errorf("%s: code %q, want %q", tt.query, body.Error.Code, tt.err)
+		}
+		if tt.code == 200 && body.Title != "Pilot" {
+			t.Errorf("%s: Title = %q", tt.query, body.Title)
+		}
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	startFakeOMDb(t, newFakeCatalog())
+	tests := []struct {
+		query   string
+		count   int
+		hasMore bool
+		first   string
+	}{
+		{"page=1", 10, true, "The Film 1"},
+		{"page=2", 10, true, "The Film 11"},
+		{"page=3", 5, false, "The Film 21"},
+		{"page=4", 0, false, ""},
+		{"page=1&depth=2", 20, true, "The Film 1"},
+		{"page=2&depth=5", 15, false, "The Film 11"},
+	}
+	for _, tt := range tests {
+		var body searchResponse
+		if code := get(t, "/api/search?query=the+film&"+tt.query, &body); code != 200 {
+			t.Fatalf("%s: status %d", tt.query, code)
+		}
+		if body.TotalResults != 25 || body.TotalPages != 3 {
+			t.Errorf("%s: totalResults %d, totalPages %d", tt.query, body.TotalResults, body.TotalPages)
+		}
+		if body.Count != tt.count || len(body.Results) != tt.count || body.HasMore != tt.hasMore {
+			t.Errorf("%s: count %d (%d results), hasMore %v, want %d, %v", tt.query, body.Count, len(body.Results), body.HasMore, tt.count, tt.hasMore)
+		}
+		if len(body.Results) > 0 && body.Results[0].Title != tt.first {
+			t.Errorf("%s: first result %q, want %q", tt.query, body.Results[0].Title, tt.first)
+		}
+	}
+	for _, q := range []string{"page=0", "page=-1", "page=x"} {
+		var body errorResponse
+		if code := get(t, "/api/search?query=the+film&"+q, &body); code != 400 || body.Error.Code != codeInvalidParam {
+			t.Errorf("%s: status %d, code %q, want 400 %s", q, code, body.Error.Code, codeInvalidParam)
+		}
+	}
+}
+
+func TestGenreRatingOrder(t *testing.T) {
+	f := newFakeCatalog()
+	startFakeOMDb(t, f)
+	oldKeywords, oldPages := seedKeywords, seedPages
+	seedKeywords, seedPages = []string{"the film"}, 3
+	t.Cleanup(func() { seedKeywords, seedPages = oldKeywords, oldPages })
+	var body genreResponse
+	if code := get(t, "/api/movies/genre?genre=drama&limit=100", &body); code != 200 {
+		t.Fatalf("status %d", code)
+	}
+	want := 0
+	for _, m := range f.movies {
+		if strings.Contains(m.Genre, "Drama") && strings.HasPrefix(m.Title, "The Film") {
+			want++
+		}
+	}
+	if len(body.Movies) != want {
+		t.Fatalf("got %d movies, want %d", len(body.Movies), want)
+	}
+	rank := byRating(missingLast)
+	for i := 1; i < len(body.Movies); i++ {
+		prev, cur := body.Movies[i-1], body.Movies[i]
+		if rank(&Movie{Title: cur.Title, ImdbRating: cur.ImdbRating}, &Movie{Title: prev.Title, ImdbRating: prev.ImdbRating}) {
+			t.Errorf("%s (%s) ranked below %s (%s)", cur.Title, cur.ImdbRating, prev.Title, prev.ImdbRating)
+		}
+	}
+	if last := body.Movies[len(body.Movies)-1]; last.ImdbRating != "N/A" {
+		t.Errorf("last is %s (%s), want the unrated one", last.Title, last.ImdbRating)
+	}
+}
+
+func TestCompareHandler(t *testing.T) {
+	startFakeOMDb(t, newFakeCatalog())
+	// compareSide embeds an unexported pointer, which encoding/json can't
+	// decode into, so the sides are read as plain compareMovies.
+	var got struct {
+		A, B         compareMovie
+		RuntimeDelta *int `json:"runtimeDelta"`
+		Winners      compareWinners
+	}
+	if code := get(t, "/api/compare?a=Inception&b=tt0000001", &got); code != 200 {
+		t.Fatalf("status %d", code)
+	}
+	a, b := got.A, got.B
+	if a.Title != "Inception" || b.Title != "The Film 1" {
+		t.Fatalf("got %+v", got)
+	}
+	if a.Runtime == nil || *a.Runtime != "148 min" {
+		t.Errorf("a.Runtime = %v, want 148 min", a.Runtime)
+	}
+	// Inception's BoxOffice is "N/A"; The Film 1 has no Runtime or BoxOffice.
+	if a.BoxOffice != nil || b.Runtime != nil || b.BoxOffice != nil {
+		t.Errorf("a.BoxOffice = %v, b.Runtime = %v, b.BoxOffice = %v, want null", a.BoxOffice, b.Runtime, b.BoxOffice)
+	}
+	if got.RuntimeDelta != nil || got.Winners.BoxOffice != "" {
+		t.Errorf("runtimeDelta = %v, winners.BoxOffice = %q, want neither", got.RuntimeDelta, got.Winners.BoxOffice)
+	}
+}
+
+func TestParseBoxOffice(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"$858,373,000", 858373000, true},
+		{"858,373,000", 858373000, true},
+		{" $1,000 ", 1000, true},
+		{"N/A", 0, false},
+		{"", 0, false},
+		{"$", 0, false},
+		{"€1,000", 0, false},
+		{"$-5", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseBoxOffice(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseBoxOffice(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMovieHandlerCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		malformed bool
+		code      int
+		err       errCode
+		title     string
+	}{
+		{"exact title", "title=Inception", false, 200, "", "Inception"},
+		{"by id", "id=tt1375666", false, 200, "", "Inception"},
+		{"search fallback", "title=Film+21", false, 200, "", "The Film 21"},
+		{"not found", "title=Nothing+Like+It", false, 404, codeNotFound, ""},
+		{"unknown id", "id=tt0000000", false, 404, codeNotFound, ""},
+		{"malformed JSON", "title=Inception", true, 502, codeUpstream, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeCatalog()
+			f.malformed = tt.malformed
+			startFakeOMDb(t, f)
+			var body struct {
+				movieLookupResponse
+				errorResponse
+			}
+			if code := get(t, "/api/movie?"+tt.query, &body); code != tt.code {
+				t.Errorf("status %d, want %d", code, tt.code)
+			}
+			if body.Error.Code != tt.err || body.Title != tt.title {
+				t.Errorf("code %q, Title %q, want %q, %q", body.Error.Code, body.Title, tt.err, tt.title)
+			}
+		})
+	}
+}
+
+func TestEpisodeHandlerCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		malformed bool
+		code      int
+		err       errCode
+	}{
+		{"found", "series_title=breaking+bad&season=1&episode_number=1", false, 200, ""},
+		{"no such episode", "series_title=Breaking+Bad&season=1&episode_number=99", false, 404, codeNotFound},
+		{"no such series", "series_title=Nope&season=1&episode_number=1", false, 404, codeNotFound},
+		{"malformed JSON", "series_title=Breaking+Bad&season=1&episode_number=1", true, 502, codeUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeCatalog()
+			f.malformed = tt.malformed
+			startFakeOMDb(t, f)
+			var body errorResponse
+			if code := get(t, "/api/episode?"+tt.query, &body); code != tt.code {
+				t.Errorf("status %d, want %d", code, tt.code)
+			}
+			if body.Error.Code != tt.err {
+				t.Errorf("code %q, want %q", body.Error.Code, tt.err)
+			}
+		})
+	}
+}
+
+func TestSearchPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyword   string
+		page      int
+		typ       string
+		malformed bool
+		ok        bool
+		items     int
+		wantErr   bool
+	}{
+		{"first page", "the film", 1, "", false, true, 10, false},
+		{"last page", "the film", 3, "", false, true, 5, false},
+		{"type filter", "bad", 1, "series", false, true, 1, false},
+		{"type filtered out", "bad", 1, "movie", false, false, 0, false},
+		{"past the end", "the film", 4, "", false, false, 0, false},
+		{"not found", "zzz", 1, "", false, false, 0, false},
+		{"malformed JSON", "the film", 1, "", true, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeCatalog()
+			f.malformed = tt.malformed
+			startFakeOMDb(t, f)
+			sr, err := searchPage(context.Background(), tt.keyword, tt.page, tt.typ, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if sr.ok() != tt.ok || len(sr.Search) != tt.items {
+				t.Errorf("ok %v with %d items, want %v with %d", sr.ok(), len(sr.Search), tt.ok, tt.items)
+			}
+			if !tt.ok && !tt.wantErr && omdbErrorStatus(sr.Error) != 404 {
+				t.Errorf("Error %q doesn't read as not found", sr.Error)
+			}
+		})
+	}
+}
+
+func TestRatingVal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"8.8", 8.8},
+		{"10", 10},
+		{"N/A", 0},
+		{"", 0},
+		{"8,8", 0},
+		{"eight", 0},
+	}
+	for _, tt := range tests {
+		if got := ratingVal(&Movie{ImdbRating: tt.in}); got != tt.want {
+			t.Errorf("ratingVal(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
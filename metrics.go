@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsPath is where promhttp is served, set from METRICS_PATH.
+var metricsPath = "/metrics"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Requests served, by route, method and status.",
+	}, []string{"route", "method", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Request latency by route.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"route"})
+	omdbCalls = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "omdb_calls_total",
+		Help: "HTTP requests sent to OMDb, counting each retry.",
+	})
+	omdbErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "omdb_errors_total",
+		Help: "OMDb fetches that failed after all retries.",
+	})
+	omdbFetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "omdb_fetch_duration_seconds",
+		Help:    "Time spent in fetchJSON, including retries and rate limiting.",
+		Buckets: prometheus.DefBuckets,
+	})
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "detail_cache_lookups_total",
+		Help: "Detail cache lookups by result (hit, negative_hit or miss).",
+	}, []string{"result"})
+	cacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "detail_cache_evictions_total",
+		Help: "Detail cache entries evicted to stay within CACHE_MAX_ENTRIES.",
+	})
+)
+
+// metrics records the count and latency of every request under its route
+// pattern, so /api/movie/id/:imdbID is one series rather than one per ID.
+func metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ctxKey int
+
+const (
+	upstreamCallsKey ctxKey = iota
+	requestIDKey
+)
+
+var accessLog = log.New(os.Stdout, "", 0)
+
+// quietLogs drops the per-request access line, set with LOG_LEVEL=quiet.
+// Request IDs and OMDb call logging are unaffected.
+var quietLogs bool
+
+// lookupDeadline bounds endpoints that make a handful of OMDb calls;
+// crawlDeadline those that fan out into dozens, and can be changed with
+// REQUEST_BUDGET.
+const lookupDeadline = 15 * time.Second
+
+var crawlDeadline = 60 * time.Second
+
+// withDeadline puts a deadline on the request context, which every OMDb
+// fetch made on the request's behalf inherits.
+func withDeadline(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// timedOut writes a 504 and returns true if the request's deadline passed.
+func timedOut(c *gin.Context) bool {
+	if c.Request.Context().Err() != context.DeadlineExceeded {
+		return false
+	}
+	respondError(c, 504, codeTimeout, "timed out waiting for OMDb")
+	return true
+}
+
+// countUpstreamCall bumps the per-request OMDb call counter, if ctx carries one.
+func countUpstreamCall(ctx context.Context) {
+	if n, ok := ctx.Value(upstreamCallsKey).(*int64); ok {
+		atomic.AddInt64(n, 1)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// validRequestID limits incoming IDs to what is safe to echo in a header
+// and a log line.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// logEvent writes a JSON log line tagged with ctx's request ID.
+func logEvent(ctx context.Context, fields map[string]interface{}) {
+	fields["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	if id := requestID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	line, _ := json.Marshal(fields)
+	accessLog.Println(string(line))
+}
+
+// requestLogger tags each request with an ID, echoed in X-Request-ID, and
+// writes one JSON line per request including how many OMDb calls it made.
+// An X-Request-ID set by a gateway is kept so its logs and ours line up;
+// otherwise a UUID is generated.
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		var calls int64
+		id := c.GetHeader("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		ctx := context.WithValue(c.Request.Context(), upstreamCallsKey, &calls)
+		c.Request = c.Request.WithContext(context.WithValue(ctx, requestIDKey, id))
+		c.Header("X-Request-ID", id)
+		c.Next()
+		if quietLogs {
+			return
+		}
+		line, _ := json.Marshal(map[string]interface{}{
+			"time":       start.UTC().Format(time.RFC3339Nano),
+			"request_id": id,
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"query":      c.Request.URL.RawQuery,
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"omdb_calls": atomic.LoadInt64(&calls),
+		})
+		accessLog.Println(string(line))
+	}
+}
+
+// allowedOrigins is set from ALLOWED_ORIGINS; "*" allows any origin.
+var allowedOrigins = []string{"*"}
+
+// corsMaxAge is how long browsers may cache a preflight, from CORS_MAX_AGE.
+var corsMaxAge = 10 * time.Minute
+
+func loadAllowedOrigins(v string) {
+	var out []string
+	for _, o := range strings.Split(v, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			out = append(out, o)
+		}
+	}
+	if len(out) > 0 {
+		allowedOrigins = out
+	}
+}
+
+func allowOrigin(origin string) string {
+	for _, o := range allowedOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// cors sets the CORS headers for allowed origins and answers preflight
+// requests directly.
+func cors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if allow := allowOrigin(c.GetHeader("Origin")); allow != "" {
+			h := c.Writer.Header()
+			h.Set("Access-Control-Allow-Origin", allow)
+			h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-User-ID, X-Request-ID, X-API-Key, If-None-Match")
+			h.Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			if allow != "*" {
+				h.Add("Vary", "Origin")
+			}
+		}
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(204)
+			return
+		}
+		c.Next()
+	}
+}
+
+// serviceKeys are the keys clients must send in X-API-Key to use /api, set
+// from SERVICE_API_KEYS. Empty means no key is needed, for local use.
+var serviceKeys []string
+
+func loadServiceKeys(v string) {
+	serviceKeys = nil
+	for _, k := range strings.Split(v, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			serviceKeys = append(serviceKeys, k)
+		}
+	}
+}
+
+// requireAPIKey answers 401 UNAUTHORIZED unless X-API-Key is one of
+// serviceKeys. Every key is compared, in constant time, so the response
+// time doesn't say which one nearly matched.
+func requireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(serviceKeys) == 0 {
+			c.Next()
+			return
+		}
+		got := []byte(c.GetHeader("X-API-Key"))
+		ok := 0
+		for _, k := range serviceKeys {
+			ok |= subtle.ConstantTimeCompare(got, []byte(k))
+		}
+		if ok != 1 {
+			respondError(c, 401, codeUnauthorized, "missing or invalid X-API-Key")
+			return
+		}
+		c.Next()
+	}
+}
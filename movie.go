@@ -0,0 +1,44 @@
+package main
+
+// Rating is one entry of OMDb's Ratings array.
+type Rating struct {
+	Source string `json:"Source" xml:"Source"`
+	Value  string `json:"Value" xml:"Value"`
+}
+
+// Movie is an OMDb title record. Series and episodes come back in the same
+// shape with the series/episode fields filled in.
+type Movie struct {
+	Title        string      `json:"Title"`
+	Year         string      `json:"Year"`
+	Rated        string      `json:"Rated"`
+	Released     string      `json:"Released"`
+	Runtime      string      `json:"Runtime"`
+	Genre        string      `json:"Genre"`
+	Director     string      `json:"Director"`
+	Writer       string      `json:"Writer"`
+	Actors       string      `json:"Actors"`
+	Plot         string      `json:"Plot"`
+	Language     string      `json:"Language"`
+	Country      string      `json:"Country"`
+	Awards       string      `json:"Awards"`
+	Poster       string      `json:"Poster"`
+	Ratings      []Rating    `json:"Ratings"`
+	Metascore    string      `json:"Metascore"`
+	ImdbRating   string      `json:"imdbRating"`
+	ImdbVotes    string      `json:"imdbVotes"`
+	ImdbID       string      `json:"imdbID"`
+	Type         string      `json:"Type"`
+	DVD          string      `json:"DVD"`
+	BoxOffice    string      `json:"BoxOffice"`
+	Production   string      `json:"Production"`
+	Website      string      `json:"Website"`
+	TotalSeasons string      `json:"totalSeasons"`
+	Season       string      `json:"Season"`
+	Episode      string      `json:"Episode"`
+	SeriesID     string      `json:"seriesID"`
+	Response     interface{} `json:"Response"`
+	Error        string      `json:"Error"`
+}
+
+func (m *Movie) ok() bool { return isOMDBSuccess(m.Response, m.Error) }
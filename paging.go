@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// omdbPageSize is how many results OMDb returns per search page.
+const omdbPageSize = 10
+
+// pageCount is the number of search pages totalResults spans.
+func pageCount(total int) int {
+	return (total + omdbPageSize - 1) / omdbPageSize
+}
+
+// lastPage reports whether a page of n results ends the listing, which OMDb
+// signals with a short page.
+func lastPage(n int) bool {
+	return n < omdbPageSize
+}
+
+// positiveInt reads param as a positive integer and returns it in canonical
+// form, so "03" reaches OMDb as "3".
+func positiveInt(c *gin.Context, param string) (string, error) {
+	v := c.Query(param)
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return "", &paramError{param, v, "a positive integer"}
+	}
+	return strconv.Itoa(n), nil
+}
+
+// parsePage reads the 1-based page param, defaulting to 1.
+func parsePage(c *gin.Context) (int, error) {
+	v := c.Query("page")
+	if v == "" {
+		return 1, nil
+	}
+	p, err := strconv.Atoi(v)
+	if err != nil || p < 1 {
+		return 0, &paramError{"page", v, "a positive integer"}
+	}
+	return p, nil
+}
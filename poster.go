@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPosterBytes stops a misbehaving image host from streaming forever.
+const maxPosterBytes = 5 << 20
+
+// posterTTL is how long a fetched image is served from memory, set from
+// POSTER_CACHE_TTL; posterCacheBytes caps the total held. Zero TTL disables
+// the cache.
+var posterTTL = 10 * time.Minute
+
+const posterCacheBytes = 64 << 20
+
+type posterEntry struct {
+	data    []byte
+	ctype   string
+	expires time.Time
+}
+
+// posterCache holds recent images by imdbID. When a new image doesn't fit it
+// drops expired entries first, then whatever else it must, in no particular
+// order; the TTL is short enough that this rarely matters.
+type posterCache struct {
+	mu    sync.Mutex
+	m     map[string]posterEntry
+	bytes int
+}
+
+var posters = &posterCache{m: map[string]posterEntry{}}
+
+func (pc *posterCache) get(id string) (posterEntry, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	e, ok := pc.m[id]
+	if ok && time.Now().After(e.expires) {
+		pc.drop(id)
+		return posterEntry{}, false
+	}
+	return e, ok
+}
+
+func (pc *posterCache) put(id string, data []byte, ctype string) {
+	if posterTTL <= 0 || len(data) > posterCacheBytes {
+		return
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.drop(id)
+	now := time.Now()
+	for k, e := range pc.m {
+		if pc.bytes+len(data) <= posterCacheBytes {
+			break
+		}
+		if now.After(e.expires) {
+			pc.drop(k)
+		}
+	}
+	for k := range pc.m {
+		if pc.bytes+len(data) <= posterCacheBytes {
+			break
+		}
+		pc.drop(k)
+	}
+	pc.m[id] = posterEntry{data: data, ctype: ctype, expires: now.Add(posterTTL)}
+	pc.bytes += len(data)
+}
+
+func (pc *posterCache) drop(id string) {
+	if e, ok := pc.m[id]; ok {
+		pc.bytes -= len(e.data)
+		delete(pc.m, id)
+	}
+}
+
+// posterHandler serves GET /api/poster/:imdbID and the older
+// /api/poster?imdbID=tt... form. It fetches the title's poster server side
+// so browsers never load OMDb's image hosts directly.
+//
+//	@Summary	Poster image for a title
+//	@Description	Also served at GET /api/poster?imdbID=...
+//	@Tags	movies
+//	@Produce	image/jpeg
+//	@Param	imdbID	path	string	true	"imdbID"
+//	@Success	200	{file}	binary
+//	@Failure	400	{object}	errorResponse	"INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Security	APIKey
+//	@Router	/api/poster/{imdbID} [get]
+func posterHandler(c *gin.Context) {
+	id := c.Param("imdbID")
+	if id == "" {
+		if !requireParams(c, "imdbID") {
+			return
+		}
+		id = c.Query("imdbID")
+	}
+	if !imdbIDPattern.MatchString(id) {
+		respondParamError(c, &paramError{"imdbID", id, "tt followed by digits"})
+		return
+	}
+	if e, ok := posters.get(id); ok {
+		writePoster(c, e)
+		return
+	}
+	ctx := c.Request.Context()
+	m, err := getDetailByID(ctx, id)
+	if err != nil {
+		respondFetchError(c, err, "movie not found")
+		return
+	}
+	if m.Poster == "" || m.Poster == "N/A" {
+		respondError(c, 404, codeNotFound, "no poster for "+id)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", m.Poster, nil)
+	if err != nil {
+		respondError(c, 502, codeUpstream, "bad poster URL")
+		return
+	}
+	req.Header.Set("User-Agent", "go-movie-api/1.0")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if !timedOut(c) {
+			respondError(c, 502, codeUpstream, "poster fetch failed")
+		}
+		return
+	}
+	defer resp.Body.Close()
+	ct := resp.Header.Get("Content-Type")
+	if resp.StatusCode == 404 {
+		respondError(c, 404, codeNotFound, "no poster for "+id)
+		return
+	}
+	if resp.StatusCode != 200 || !strings.HasPrefix(ct, "image/") {
+		respondError(c, 502, codeUpstream, "poster host returned "+strconv.Itoa(resp.StatusCode))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPosterBytes+1))
+	if err != nil || len(data) > maxPosterBytes {
+		if !timedOut(c) {
+			respondError(c, 502, codeUpstream, "poster fetch failed")
+		}
+		return
+	}
+	e := posterEntry{data: data, ctype: ct}
+	posters.put(id, data, ct)
+	writePoster(c, e)
+}
+
+func writePoster(c *gin.Context, e posterEntry) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(200, e.ctype, e.data)
+}
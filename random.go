@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/gin-gonic/gin"
+)
+
+// randomPoolSize is how many matching titles /api/random picks among.
+const randomPoolSize = 40
+
+var randomFilterRules = []filterRule{minRatingRule}
+
+type randomResponse struct {
+	Genre    string            `json:"genre,omitempty"`
+	Type     string            `json:"type,omitempty"`
+	Filters  map[string]string `json:"filters"`
+	PoolSize int               `json:"pool_size"`
+	Movie    movieResponse     `json:"movie"`
+}
+
+// randomHandler is GET /api/random, one title picked at random from a genre
+// crawl's pool, or from everything the seed keywords find without a genre.
+// The min_rating floor is applied during the crawl so the whole pool
+// qualifies. math/rand's global source is seeded at startup, so picks vary
+// between calls and between restarts.
+//
+//	@Summary	A random movie, optionally from a genre
+//	@Tags		movies
+//	@Produce	json,xml
+//	@Param		genre		query		string	false	"Genre, e.g. Comedy"
+//	@Param		min_rating	query		number	false	"Lowest imdbRating"
+//	@Param		type		query		string	false	"Restrict to a type"	Enums(movie, series, episode)
+//	@Param		normalize	query		bool	false	"Return Ratings on a 0-100 scale"
+//	@Success	200			{object}	randomResponse
+//	@Failure	400			{object}	errorResponse	"INVALID_PARAM"
+//	@Failure	404			{object}	errorResponse	"NOT_FOUND, with the genre and filters in details"
+//	@Failure	429			{object}	errorResponse	"QUOTA_EXCEEDED"
+//	@Failure	503			{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504			{object}	errorResponse	"TIMEOUT"
+//	@Security		APIKey
+//	@Router		/api/random [get]
+func randomHandler(c *gin.Context) {
+	genre := c.Query("genre")
+	keep, applied, err := parseFilters(c, randomFilterRules)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	var stats collectStats
+	pool := collectByGenre(c.Request.Context(), genre, crawlOpts{limit: randomPoolSize, keep: keep, typ: typ, stats: &stats})
+	if timedOut(c) {
+		return
+	}
+	if stats.stopped != nil && len(pool) == 0 {
+		respondFetchError(c, stats.stopped, "")
+		return
+	}
+	if len(pool) == 0 {
+		respondErrorDetails(c, 404, codeNotFound, "no movies matched", gin.H{"genre": genre, "filters": applied})
+		return
+	}
+	m := pool[rand.Intn(len(pool))]
+	respond(c, 200, randomResponse{Genre: genre, Type: typ, Filters: applied, PoolSize: len(pool), Movie: movieBody(c, m)})
+}
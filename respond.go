@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// implementedFormats are the response formats respond can write.
+var implementedFormats = []string{"json", "xml"}
+
+// enabledFormats is the deployment's allowlist, set from
+// RESPONSE_FORMATS_ENABLED. By default every implemented format is enabled.
+var enabledFormats = map[string]bool{"json": true, "xml": true}
+
+func loadResponseFormats(v string) {
+	if strings.TrimSpace(v) == "" {
+		return
+	}
+	known := map[string]bool{}
+	for _, f := range implementedFormats {
+		known[f] = true
+	}
+	enabled := map[string]bool{}
+	for _, f := range strings.Split(v, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !known[f] {
+			log.Printf("RESPONSE_FORMATS_ENABLED: ignoring unknown format %q", f)
+			continue
+		}
+		enabled[f] = true
+	}
+	if len(enabled) == 0 {
+		log.Println("RESPONSE_FORMATS_ENABLED: no usable formats, keeping json")
+		enabled["json"] = true
+	}
+	enabledFormats = enabled
+}
+
+// requestedFormat is the format param, or else xml when Accept ranks an XML
+// media type above every other named type, JSON included, and json
+// otherwise. Wildcards don't count, so a browser's Accept, which prefers
+// text/html and lists application/xml below it, still gets JSON, and a tie
+// goes to JSON too.
+func requestedFormat(c *gin.Context) string {
+	if f := strings.ToLower(c.Query("format")); f != "" {
+		return f
+	}
+	var xmlQ, otherQ float64
+	for _, t := range strings.Split(c.GetHeader("Accept"), ",") {
+		t, params, _ := strings.Cut(t, ";")
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		switch t = strings.ToLower(strings.TrimSpace(t)); {
+		case t == "application/xml" || t == "text/xml":
+			xmlQ = max(xmlQ, q)
+		case t != "" && !strings.HasSuffix(t, "/*"):
+			otherQ = max(otherQ, q)
+		}
+	}
+	if xmlQ > 0 && xmlQ > otherQ {
+		return "xml"
+	}
+	return "json"
+}
+
+// writeBody writes body as XML when that was asked for and is enabled, and
+// as JSON otherwise.
+func writeBody(c *gin.Context, status int, body interface{}) {
+	if requestedFormat(c) != "xml" || !enabledFormats["xml"] {
+		c.JSON(status, body)
+		return
+	}
+	b, err := marshalXML(body)
+	if err != nil {
+		c.JSON(500, errorResponse{apiError{Code: codeInternal, Message: "could not encode response"}})
+		return
+	}
+	c.Data(status, "application/xml; charset=utf-8", b)
+}
+
+// respond writes a successful body in the format the client asked for, or a
+// 400 UNSUPPORTED_FORMAT when that format isn't enabled here.
+func respond(c *gin.Context, status int, body interface{}) {
+	f := requestedFormat(c)
+	if !enabledFormats[f] {
+		respondError(c, 400, codeUnsupportedFormat, "unsupported format "+f)
+		return
+	}
+	writeBody(c, status, body)
+}
+
+// errCode is the machine-readable half of every error response.
+type errCode string
+
+const (
+	codeMissingParam      errCode = "MISSING_PARAM"
+	codeInvalidParam      errCode = "INVALID_PARAM"
+	codeInvalidBody       errCode = "INVALID_BODY"
+	codeNotFound          errCode = "NOT_FOUND"
+	codeUpstream          errCode = "UPSTREAM_ERROR"
+	codeRateLimited       errCode = "RATE_LIMITED"
+	codeQuotaExceeded     errCode = "QUOTA_EXCEEDED"
+	codeTimeout           errCode = "TIMEOUT"
+	codeUnauthorized      errCode = "UNAUTHORIZED"
+	codeUnsupportedFormat errCode = "UNSUPPORTED_FORMAT"
+	codeInternal          errCode = "INTERNAL_ERROR"
+)
+
+// respondError writes {"error":{"code":...,"message":...}} and aborts the
+// chain, so it is safe to call from middleware too. Errors are XML too for
+// a client that asked for XML.
+func respondError(c *gin.Context, status int, code errCode, msg string) {
+	respondErrorDetails(c, status, code, msg, nil)
+}
+
+// respondErrorDetails is respondError with a "details" value for clients that
+// want more than the message, such as which param was wrong.
+func respondErrorDetails(c *gin.Context, status int, code errCode, msg string, details interface{}) {
+	c.Abort()
+	writeBody(c, status, errorResponse{apiError{code, msg, details}})
+}
+
+// paramError is a query param with a value we can't use.
+type paramError struct {
+	param, value, want string
+}
+
+func (e *paramError) Error() string {
+	msg := fmt.Sprintf("invalid %s %q", e.param, e.value)
+	if e.want != "" {
+		msg += ", want " + e.want
+	}
+	return msg
+}
+
+// respondParamError writes a 400 INVALID_PARAM for err, naming the param and
+// value in details when err is a paramError.
+func respondParamError(c *gin.Context, err error) {
+	var pe *paramError
+	if errors.As(err, &pe) {
+		respondErrorDetails(c, 400, codeInvalidParam, pe.Error(), gin.H{"param": pe.param, "value": pe.value})
+		return
+	}
+	respondError(c, 400, codeInvalidParam, err.Error())
+}
+
+// requireParams writes a 400 MISSING_PARAM listing whichever of names are
+// absent, and reports whether all were present.
+func requireParams(c *gin.Context, names ...string) bool {
+	var missing []string
+	for _, n := range names {
+		if c.Query(n) == "" {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+	respondErrorDetails(c, 400, codeMissingParam, "missing "+strings.Join(missing, ", "), gin.H{"missing": missing})
+	return false
+}
+
+// selectFields trims body to the comma separated keys in the fields param,
+// matched case-insensitively. With no fields param body is returned as is.
+// An unknown name writes a 400 listing the valid ones and reports false.
+func selectFields(c *gin.Context, body interface{}) (interface{}, bool) {
+	v := c.Query("fields")
+	if v == "" {
+		return body, true
+	}
+	var all map[string]json.RawMessage
+	b, err := json.Marshal(body)
+	if err == nil {
+		err = json.Unmarshal(b, &all)
+	}
+	if err != nil {
+		respondError(c, 500, codeInternal, "could not encode response")
+		return nil, false
+	}
+	known := make(map[string]string, len(all))
+	for k := range all {
+		known[strings.ToLower(k)] = k
+	}
+	out := gin.H{}
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		k, ok := known[strings.ToLower(f)]
+		if !ok {
+			valid := make([]string, 0, len(all))
+			for k := range all {
+				valid = append(valid, k)
+			}
+			sort.Strings(valid)
+			respondErrorDetails(c, 400, codeInvalidParam, fmt.Sprintf("unknown field %q", f), gin.H{"param": "fields", "value": f, "valid": valid})
+			return nil, false
+		}
+		out[k] = all[k]
+	}
+	return out, true
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestedFormat(t *testing.T) {
+	tests := []struct {
+		query, accept, want string
+	}{
+		{"", "", "json"},
+		{"", "application/json", "json"},
+		{"", "application/xml", "xml"},
+		{"", "text/xml", "xml"},
+		{"", "*/*", "json"},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "json"},
+		{"", "application/xml, application/json", "json"},
+		{"", "application/json;q=0.5, application/xml", "xml"},
+		{"", "application/xml;q=0.9, */*", "xml"},
+		{"", "application/xml;q=0", "json"},
+		{"format=xml", "application/json", "xml"},
+		{"format=JSON", "application/xml", "json"},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/movie?"+tt.query, nil)
+		c.Request.Header.Set("Accept", tt.accept)
+		if got := requestedFormat(c); got != tt.want {
+			t.Errorf("format=%q Accept %q: %s, want %s", tt.query, tt.accept, got, tt.want)
+		}
+	}
+}
+
+// The tagged movie types are encoded by encoding/xml but should come out as
+// the JSON transcoder would write them.
+func TestMovieXMLMatchesTranscoder(t *testing.T) {
+	rated, minutes := "PG-13", 148
+	body := movieLookupResponse{
+		movieResponse: movieResponse{
+			Title: "Inception", Year: "2010", Rated: &rated, Awards: "N/A",
+			Ratings:        []Rating{{"Internet Movie Database", "8.8/10"}, {"Metacritic", "74/100"}},
+			RuntimeMinutes: &minutes,
+		},
+		DidYouMean: []titleRef{{"Inception 2", "2030", "tt0000002"}},
+	}
+	for _, ratings := range []interface{}{body.Ratings, []normRating{{"Metacritic", "74/100", 74}}} {
+		body.Ratings = ratings
+		got, err := marshalXML(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(body)
+		want, err := marshalXML(json.RawMessage(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("encoding/xml:\n%s\ntranscoder:\n%s", got, want)
+		}
+	}
+}
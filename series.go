@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seriesHandler is GET /api/series?title=...&season=N. With a season it
+// returns that season's episode guide, as /api/season does; without one it
+// returns the series itself, with totalSeasons as a number. A title that
+// isn't a series is a 404.
+//
+//	@Summary	Series metadata, or one season's episodes
+//	@Description	Without season the response is a seriesResponse; with it, a seasonResponse.
+//	@Tags	series
+//	@Produce	json,xml
+//	@Param	title	query	string	true	"Series title"
+//	@Param	season	query	int	false	"Season number"
+//	@Success	200	{object}	seriesResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	429	{object}	errorResponse	"RATE_LIMITED, QUOTA_EXCEEDED"
+//	@Failure	502	{object}	errorResponse	"UPSTREAM_ERROR"
+//	@Failure	503	{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504	{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router	/api/series [get]
+func seriesHandler(c *gin.Context) {
+	t := c.Query("title")
+	if !requireParams(c, "title") {
+		return
+	}
+	if c.Query("season") != "" {
+		se, err := positiveInt(c, "season")
+		if err != nil {
+			respondParamError(c, err)
+			return
+		}
+		respondSeason(c, t, se)
+		return
+	}
+	var m Movie
+	if err := fetchJSON(c.Request.Context(), omdbURL(map[string]string{"t": t, "type": "series"}), &m); err != nil {
+		respondFetchError(c, err, "series not found")
+		return
+	}
+	if !m.ok() {
+		respondOMDBError(c, m.Error, "series not found")
+		return
+	}
+	if m.Type != "series" {
+		respondError(c, 404, codeNotFound, t+" is not a series")
+		return
+	}
+	n, _ := strconv.Atoi(m.TotalSeasons)
+	respond(c, 200, seriesResponse{
+		Title:        m.Title,
+		Year:         m.Year,
+		ImdbID:       m.ImdbID,
+		TotalSeasons: nullableInt(n),
+		Genre:        m.Genre,
+		Plot:         m.Plot,
+		ImdbRating:   m.ImdbRating,
+	})
+}
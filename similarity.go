@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// minTitleScore is the lowest titleSimilarity a search hit needs to stand in
+// for a title the exact lookup missed.
+const minTitleScore = 0.6
+
+// normTitle lower-cases v, drops punctuation and collapses spaces, so
+// "Spider-Man: Homecoming" and "spider man homecoming" compare equal.
+func normTitle(v string) []rune {
+	f := strings.FieldsFunc(strings.ToLower(v), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return []rune(strings.Join(f, " "))
+}
+
+// levenshtein is the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func ratio(a, b []rune) float64 {
+	n := max(len(a), len(b))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(n)
+}
+
+// titleSimilarity scores how well title answers query, from 0 to 1: the
+// Levenshtein ratio of the two, or of query and the part of title before a
+// colon if that is closer, so "Star Wars" still finds "Star Wars: Episode
+// IV - A New Hope". It is rounded to two places.
+func titleSimilarity(query, title string) float64 {
+	q := normTitle(query)
+	s := ratio(q, normTitle(title))
+	if main, _, ok := strings.Cut(title, ":"); ok {
+		s = max(s, ratio(q, normTitle(main)))
+	}
+	return math.Round(s*100) / 100
+}
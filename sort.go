@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ranking reports whether a should be listed before b.
+type ranking func(a, b *Movie) bool
+
+// missingPlacement says where movies lacking the sort field land.
+type missingPlacement string
+
+const (
+	missingFirst missingPlacement = "first"
+	missingLast  missingPlacement = "last"
+)
+
+// sortKey is one field movies can be sorted on. less is the ascending
+// order; has reports whether the field is present at all.
+type sortKey struct {
+	has         func(*Movie) bool
+	less        func(a, b *Movie) bool
+	defaultDesc bool
+}
+
+var sortKeys = map[string]sortKey{
+	"rating": {hasRating, func(a, b *Movie) bool { return ratingVal(a) < ratingVal(b) }, true},
+	"year":   {func(m *Movie) bool { return movieYear(m) > 0 }, func(a, b *Movie) bool { return movieYear(a) < movieYear(b) }, true},
+	"title": {func(m *Movie) bool { return m.Title != "" }, func(a, b *Movie) bool {
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	}, false},
+}
+
+// parseMissing reads the missing=first|last param, defaulting to last.
+func parseMissing(c *gin.Context) (missingPlacement, error) {
+	switch v := missingPlacement(c.DefaultQuery("missing", string(missingLast))); v {
+	case missingFirst, missingLast:
+		return v, nil
+	default:
+		return "", &paramError{"missing", string(v), "first or last"}
+	}
+}
+
+// parseSort reads sort=rating|year|title, order=asc|desc and missing. The
+// default is rating, and each key has its natural default order: newest
+// and best rated first, titles A to Z. It also returns the key and order
+// actually applied.
+func parseSort(c *gin.Context) (ranking, string, string, error) {
+	name := c.DefaultQuery("sort", "rating")
+	k, ok := sortKeys[name]
+	if !ok {
+		return nil, "", "", &paramError{"sort", name, "rating, year or title"}
+	}
+	desc := k.defaultDesc
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return nil, "", "", &paramError{"order", c.Query("order"), "asc or desc"}
+	}
+	missing, err := parseMissing(c)
+	if err != nil {
+		return nil, "", "", err
+	}
+	order := "asc"
+	if desc {
+		order = "desc"
+	}
+	return byKey(k, desc, missing), name, order, nil
+}
+
+// byKey orders on k. Movies without the field go where missing says
+// regardless of direction, and ties fall back to title then imdbID so the
+// order never depends on arrival.
+func byKey(k sortKey, desc bool, missing missingPlacement) ranking {
+	return func(a, b *Movie) bool {
+		ha, hb := k.has(a), k.has(b)
+		if ha != hb {
+			return ha == (missing == missingLast)
+		}
+		x, y := a, b
+		if desc {
+			x, y = b, a
+		}
+		if k.less(x, y) {
+			return true
+		}
+		if k.less(y, x) {
+			return false
+		}
+		if ta, tb := strings.ToLower(a.Title), strings.ToLower(b.Title); ta != tb {
+			return ta < tb
+		}
+		return a.ImdbID < b.ImdbID
+	}
+}
+
+func byRating(missing missingPlacement) ranking {
+	return byKey(sortKeys["rating"], true, missing)
+}
+
+func hasRating(m *Movie) bool {
+	return ratingVal(m) > 0
+}
+
+// sortMovies sorts list in place by better and returns at most n of it.
+func sortMovies(list []*Movie, better ranking, n int) []*Movie {
+	sort.SliceStable(list, func(i, j int) bool { return better(list[i], list[j]) })
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
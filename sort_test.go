@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestByKey(t *testing.T) {
+	a := &Movie{Title: "Alpha", Year: "2010", ImdbRating: "6.0", ImdbID: "tt1"}
+	b := &Movie{Title: "beta", Year: "1999", ImdbRating: "8.0", ImdbID: "tt2"}
+	c := &Movie{Title: "Gamma", Year: "2005", ImdbRating: "7.0", ImdbID: "tt3"}
+	none := &Movie{Year: "N/A", ImdbRating: "N/A", ImdbID: "tt9"}
+	ascending := map[string][]*Movie{
+		"rating": {a, c, b},
+		"year":   {b, c, a},
+		"title":  {a, b, c},
+	}
+	for name, asc := range ascending {
+		for _, desc := range []bool{false, true} {
+			for _, missing := range []missingPlacement{missingFirst, missingLast} {
+				want := slices.Clone(asc)
+				if desc {
+					slices.Reverse(want)
+				}
+				if missing == missingFirst {
+					want = append([]*Movie{none}, want...)
+				} else {
+					want = append(want, none)
+				}
+				got := sortMovies([]*Movie{c, none, b, a}, byKey(sortKeys[name], desc, missing), 10)
+				if !slices.Equal(got, want) {
+					t.Errorf("sort=%s desc=%v missing=%s: got %s, want %s", name, desc, missing, ids(got), ids(want))
+				}
+			}
+		}
+	}
+}
+
+func TestParseMissing(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    missingPlacement
+		wantErr bool
+	}{
+		{"", missingLast, false},
+		{"missing=last", missingLast, false},
+		{"missing=first", missingFirst, false},
+		{"missing=top", "", true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+		got, err := parseMissing(c)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%q: got %q, %v", tt.query, got, err)
+		}
+	}
+}
+
+func ids(list []*Movie) string {
+	out := make([]string, len(list))
+	for i, m := range list {
+		out[i] = m.ImdbID
+	}
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Counters behind /api/stats. They overlap with the Prometheus metrics but
+// are cheap to read as a whole, for people without a metrics stack who
+// want to know how much of their OMDb quota goes where.
+var (
+	startedAt = time.Now()
+
+	omdbCallCount   atomic.Int64
+	omdbLatencyNano atomic.Int64
+	callsByID       atomic.Int64
+	callsByTitle    atomic.Int64
+	callsBySearch   atomic.Int64
+	callsOther      atomic.Int64
+
+	cacheHits         atomic.Int64
+	cacheNegativeHits atomic.Int64
+	cacheMisses       atomic.Int64
+)
+
+// recordOMDBCall counts one attempt at u by its lookup kind and adds its
+// latency to the running total.
+func recordOMDBCall(u string, d time.Duration) {
+	omdbCallCount.Add(1)
+	omdbLatencyNano.Add(int64(d))
+	q := url.Values{}
+	if p, err := url.Parse(u); err == nil {
+		q = p.Query()
+	}
+	switch {
+	case q.Has("i"):
+		callsByID.Add(1)
+	case q.Has("t"):
+		callsByTitle.Add(1)
+	case q.Has("s"):
+		callsBySearch.Add(1)
+	default:
+		callsOther.Add(1)
+	}
+}
+
+type statsResponse struct {
+	UptimeSeconds int64      `json:"uptime_seconds"`
+	StartedAt     string     `json:"started_at"`
+	OMDB          omdbStats  `json:"omdb"`
+	Cache         cacheStats `json:"cache"`
+}
+
+type omdbStats struct {
+	Calls        int64            `json:"calls"`
+	ByType       map[string]int64 `json:"by_type"`
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+}
+
+type cacheStats struct {
+	Hits         int64 `json:"hits"`
+	NegativeHits int64 `json:"negative_hits"`
+	Misses       int64 `json:"misses"`
+	Entries      int   `json:"entries"`
+}
+
+// statsHandler is GET /api/stats, cumulative OMDb and cache counters since
+// the process started. Every retry is a call; by_type splits them into
+// imdbID (i), title (t) and search (s) lookups.
+//
+//	@Summary	OMDb usage and cache counters since start
+//	@Tags		health
+//	@Produce	json,xml
+//	@Success	200	{object}	statsResponse
+//	@Security		APIKey
+//	@Router		/api/stats [get]
+func statsHandler(c *gin.Context) {
+	calls := omdbCallCount.Load()
+	avg := 0.0
+	if calls > 0 {
+		avg = math.Round(float64(omdbLatencyNano.Load())/float64(calls)/float64(time.Millisecond)*10) / 10
+	}
+	respond(c, 200, statsResponse{
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+		StartedAt:     startedAt.UTC().Format(time.RFC3339),
+		OMDB: omdbStats{
+			Calls: calls,
+			ByType: map[string]int64{
+				"i":     callsByID.Load(),
+				"t":     callsByTitle.Load(),
+				"s":     callsBySearch.Load(),
+				"other": callsOther.Load(),
+			},
+			AvgLatencyMs: avg,
+		},
+		Cache: cacheStats{
+			Hits:         cacheHits.Load(),
+			NegativeHits: cacheNegativeHits.Load(),
+			Misses:       cacheMisses.Load(),
+			Entries:      details.len(),
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// rankHeap keeps the worst-ranked kept movie at [0].
+type rankHeap struct {
+	items  []*Movie
+	better ranking
+}
+
+func (h rankHeap) Len() int            { return len(h.items) }
+func (h rankHeap) Less(i, j int) bool  { return h.better(h.items[j], h.items[i]) }
+func (h rankHeap) Swap(i, j int)       { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *rankHeap) Push(x interface{}) { h.items = append(h.items, x.(*Movie)) }
+func (h *rankHeap) Pop() interface{} {
+	old := h.items
+	m := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return m
+}
+
+// topN keeps the n best movies offered to it under a ranking.
+type topN struct {
+	n int
+	h rankHeap
+}
+
+func newTopN(n int, better ranking) *topN {
+	if better == nil {
+		better = byRating(missingLast)
+	}
+	return &topN{n: n, h: rankHeap{items: make([]*Movie, 0, max(n, 0)), better: better}}
+}
+
+func (t *topN) offer(m *Movie) {
+	if t.n <= 0 {
+		return
+	}
+	if len(t.h.items) < t.n {
+		heap.Push(&t.h, m)
+		return
+	}
+	if t.h.better(m, t.h.items[0]) {
+		t.h.items[0] = m
+		heap.Fix(&t.h, 0)
+	}
+}
+
+// sorted returns the kept movies best first.
+func (t *topN) sorted() []*Movie {
+	out := make([]*Movie, len(t.h.items))
+	copy(out, t.h.items)
+	sort.SliceStable(out, func(i, j int) bool { return t.h.better(out[i], out[j]) })
+	return out
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchMovies is n movies with ratings from 1.0 to 9.9 in random order, a
+// few of them unrated.
+func benchMovies(n int) []*Movie {
+	r := rand.New(rand.NewSource(1))
+	out := make([]*Movie, n)
+	for i := range out {
+		rating := fmt.Sprintf("%.1f", 1+float64(r.Intn(90))/10)
+		if i%23 == 0 {
+			rating = "N/A"
+		}
+		out[i] = &Movie{Title: fmt.Sprintf("Movie %d", i), ImdbID: fmt.Sprintf("tt%07d", i), ImdbRating: rating}
+	}
+	return out
+}
+
+func TestTopNMatchesSortMovies(t *testing.T) {
+	movies := benchMovies(500)
+	for _, n := range []int{0, 1, 15, 500, 600} {
+		top := newTopN(n, byRating(missingLast))
+		for _, m := range movies {
+			top.offer(m)
+		}
+		got := top.sorted()
+		want := sortMovies(append([]*Movie(nil), movies...), byRating(missingLast), n)
+		if len(got) != len(want) {
+			t.Fatalf("n=%d: got %d movies, want %d", n, len(got), len(want))
+		}
+		// Ties may come out in either order, so compare the ratings.
+		for i := range want {
+			if ratingVal(got[i]) != ratingVal(want[i]) {
+				t.Fatalf("n=%d: [%d] is rated %v, want %v", n, i, ratingVal(got[i]), ratingVal(want[i]))
+			}
+		}
+	}
+}
+
+// The genre endpoint keeps the best 15 of up to 150 matches; topN only ever
+// holds 15 of them where collect-then-sort holds and sorts them all.
+func BenchmarkTopN(b *testing.B) {
+	for _, size := range []int{150, 10000} {
+		movies := benchMovies(size)
+		b.Run(fmt.Sprintf("heap/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				top := newTopN(15, byRating(missingLast))
+				for _, m := range movies {
+					top.offer(m)
+				}
+				top.sorted()
+			}
+		})
+		b.Run(fmt.Sprintf("sort/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				sortMovies(append([]*Movie(nil), movies...), byRating(missingLast), 15)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+)
+
+// watchlistDB is opened by openWatchlist from WATCHLIST_DB.
+var watchlistDB *sql.DB
+
+// watchlistMigrations are applied in order, each once, tracked with SQLite's
+// user_version. Append new steps; never edit one that has shipped.
+var watchlistMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS watchlist (
+		imdb_id  TEXT PRIMARY KEY,
+		title    TEXT NOT NULL,
+		year     TEXT NOT NULL,
+		poster   TEXT NOT NULL,
+		added_at TEXT NOT NULL
+	)`,
+	// Lists become per user. Rows saved before that belong to "default".
+	`CREATE TABLE watchlist_items (
+		user_id  TEXT NOT NULL,
+		imdb_id  TEXT NOT NULL,
+		title    TEXT NOT NULL,
+		year     TEXT NOT NULL,
+		poster   TEXT NOT NULL,
+		added_at TEXT NOT NULL,
+		PRIMARY KEY (user_id, imdb_id)
+	);
+	INSERT INTO watchlist_items SELECT 'default', imdb_id, title, year, poster, added_at FROM watchlist;
+	DROP TABLE watchlist`,
+}
+
+// openWatchlist opens the SQLite file at path, creating it if needed, and
+// brings its schema up to date. SQLite allows one writer at a time, so the
+// pool is a single connection and waits on a busy file instead of failing.
+func openWatchlist(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(1)
+	if err := migrate(db); err != nil {
+		db.Close()
+		return err
+	}
+	watchlistDB = db
+	return nil
+}
+
+func migrate(db *sql.DB) error {
+	var v int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&v); err != nil {
+		return err
+	}
+	for ; v < len(watchlistMigrations); v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(watchlistMigrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userHeader identifies whose watchlist a request touches.
+const userHeader = "X-User-ID"
+
+// watchUser reads userHeader, writing the 400 itself if it is missing.
+func watchUser(c *gin.Context) (string, bool) {
+	u := strings.TrimSpace(c.GetHeader(userHeader))
+	if u == "" || len(u) > 128 {
+		respondError(c, 400, codeMissingParam, "missing or invalid "+userHeader+" header")
+		return "", false
+	}
+	return u, true
+}
+
+type watchItem struct {
+	ImdbID  string `json:"imdbID"`
+	Title   string `json:"Title"`
+	Year    string `json:"Year"`
+	Poster  string `json:"Poster"`
+	AddedAt string `json:"addedAt"`
+}
+
+type watchRequest struct {
+	ImdbID string `json:"imdbID"`
+}
+
+// addWatchHandler is POST /api/watchlist {"imdbID": "tt..."}. It stores the
+// title, year and poster as well, which GET shows when refreshing the entry
+// from OMDb fails.
+//
+//	@Summary	Add a title to the watchlist
+//	@Tags	watchlist
+//	@Accept	json
+//	@Produce	json,xml
+//	@Param	X-User-ID	header	string	true	"Watchlist owner"
+//	@Param	body	body	watchRequest	true	"Title to add"
+//	@Success	201	{object}	watchAddResponse	"added"
+//	@Success	200	{object}	watchAddResponse	"already saved"
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM, INVALID_BODY"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	500	{object}	errorResponse	"INTERNAL_ERROR"
+//	@Security	APIKey
+//	@Router	/api/watchlist [post]
+func addWatchHandler(c *gin.Context) {
+	user, ok := watchUser(c)
+	if !ok {
+		return
+	}
+	var req watchRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ImdbID == "" {
+		respondError(c, 400, codeInvalidBody, "body must be {\"imdbID\": \"tt...\"}")
+		return
+	}
+	if !imdbIDPattern.MatchString(req.ImdbID) {
+		respondParamError(c, &paramError{"imdbID", req.ImdbID, "tt followed by digits"})
+		return
+	}
+	m, err := getDetailByID(c.Request.Context(), req.ImdbID)
+	if err != nil {
+		respondFetchError(c, err, "movie not found")
+		return
+	}
+	it := watchItem{ImdbID: m.ImdbID, Title: m.Title, Year: m.Year, Poster: m.Poster, AddedAt: time.Now().UTC().Format(time.RFC3339)}
+	res, err := watchlistDB.Exec(`INSERT INTO watchlist_items (user_id, imdb_id, title, year, poster, added_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, imdb_id) DO NOTHING`, user, it.ImdbID, it.Title, it.Year, it.Poster, it.AddedAt)
+	if err != nil {
+		respondError(c, 500, codeInternal, "could not save watchlist")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		watchlistDB.QueryRow(`SELECT added_at FROM watchlist_items WHERE user_id = ? AND imdb_id = ?`, user, it.ImdbID).Scan(&it.AddedAt)
+		respond(c, 200, watchAddResponse{false, it})
+		return
+	}
+	respond(c, 201, watchAddResponse{true, it})
+}
+
+// listWatchHandler returns the user's saved titles, refreshed from the
+// detail cache or OMDb. An entry whose lookup fails falls back to what was
+// stored when it was added, with hydrated false.
+//
+//	@Summary	List the watchlist
+//	@Tags	watchlist
+//	@Produce	json,xml
+//	@Param	X-User-ID	header	string	true	"Watchlist owner"
+//	@Success	200	{object}	watchlistResponse
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM"
+//	@Failure	500	{object}	errorResponse	"INTERNAL_ERROR"
+//	@Security	APIKey
+//	@Router	/api/watchlist [get]
+func listWatchHandler(c *gin.Context) {
+	user, ok := watchUser(c)
+	if !ok {
+		return
+	}
+	rows, err := watchlistDB.QueryContext(c.Request.Context(), `SELECT imdb_id, title, year, poster, added_at FROM watchlist_items
+		WHERE user_id = ? ORDER BY added_at, imdb_id`, user)
+	if err != nil {
+		respondError(c, 500, codeInternal, "could not read watchlist")
+		return
+	}
+	defer rows.Close()
+	items := []watchItem{}
+	for rows.Next() {
+		var it watchItem
+		if err := rows.Scan(&it.ImdbID, &it.Title, &it.Year, &it.Poster, &it.AddedAt); err != nil {
+			respondError(c, 500, codeInternal, "could not read watchlist")
+			return
+		}
+		items = append(items, it)
+	}
+	if rows.Err() != nil {
+		respondError(c, 500, codeInternal, "could not read watchlist")
+		return
+	}
+	ctx := c.Request.Context()
+	out := make([]watchEntry, len(items))
+	sem := make(chan struct{}, enrichWorkers)
+	var wg sync.WaitGroup
+	for i, it := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			e := watchEntry{ImdbID: it.ImdbID, AddedAt: it.AddedAt, Title: it.Title, Year: it.Year, Poster: it.Poster}
+			if m, err := getDetailByID(ctx, it.ImdbID); err == nil {
+				e.Title, e.Year, e.Poster = m.Title, m.Year, m.Poster
+				e.Genre, e.Director, e.Runtime, e.ImdbRating = m.Genre, m.Director, m.Runtime, m.ImdbRating
+				e.TotalSeasons = summarize(m).TotalSeasons
+				e.Hydrated = true
+			}
+			out[i] = e
+		}()
+	}
+	wg.Wait()
+	if timedOut(c) {
+		return
+	}
+	respond(c, 200, watchlistResponse{Count: len(out), Items: out})
+}
+
+// deleteWatchHandler is DELETE /api/watchlist/:imdbID.
+//
+//	@Summary	Remove a title from the watchlist
+//	@Tags	watchlist
+//	@Param	X-User-ID	header	string	true	"Watchlist owner"
+//	@Param	imdbID	path	string	true	"imdbID"
+//	@Success	204
+//	@Failure	400	{object}	errorResponse	"MISSING_PARAM"
+//	@Failure	404	{object}	errorResponse	"NOT_FOUND"
+//	@Failure	500	{object}	errorResponse	"INTERNAL_ERROR"
+//	@Security	APIKey
+//	@Router	/api/watchlist/{imdbID} [delete]
+func deleteWatchHandler(c *gin.Context) {
+	user, ok := watchUser(c)
+	if !ok {
+		return
+	}
+	res, err := watchlistDB.ExecContext(c.Request.Context(), `DELETE FROM watchlist_items WHERE user_id = ? AND imdb_id = ?`, user, c.Param("imdbID"))
+	if err != nil {
+		respondError(c, 500, codeInternal, "could not update watchlist")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		respondError(c, 404, codeNotFound, "not in watchlist")
+		return
+	}
+	c.Status(204)
+}
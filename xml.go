@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"regexp"
+)
+
+// xmlNamePattern is the subset of XML names a JSON key can be used as
+// directly; any other key is written as <entry key="...">.
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// marshalXML writes body as XML under a <response> root. A response type
+// with an XMLName field, such as movieResponse, carries its own xml tags and
+// is encoded by encoding/xml. Anything else goes through its JSON form, so
+// element names are the JSON keys and a fields= selection or a null field
+// comes out the same as in JSON: objects become child elements, array
+// elements become <item> children, and nulls are left out. The tagged types
+// follow the same layout.
+func marshalXML(body interface{}) ([]byte, error) {
+	if hasXMLName(body) {
+		b, err := xml.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), b...), nil
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	enc := xml.NewEncoder(&out)
+	if err := transcode(dec, enc, "response"); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func hasXMLName(body interface{}) bool {
+	t := reflect.TypeOf(body)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := t.FieldByName("XMLName")
+	return ok
+}
+
+func xmlStart(name string) xml.StartElement {
+	if xmlNamePattern.MatchString(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+}
+
+// transcode reads the next JSON value from dec and writes it to enc as an
+// element called name.
+func transcode(dec *json.Decoder, enc *xml.Encoder, name string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	start := xmlStart(name)
+	switch t := tok.(type) {
+	case json.Delim:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for dec.More() {
+			child := "item"
+			if t == '{' {
+				k, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child, _ = k.(string)
+			}
+			if err := transcode(dec, enc, child); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		return enc.EncodeElement(t, start)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var yearPattern = regexp.MustCompile(`^\d{4}$`)
+
+type yearResponse struct {
+	Year   int            `json:"year" example:"2019"`
+	Genre  string         `json:"genre,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Sort   string         `json:"sort"`
+	Order  string         `json:"order"`
+	Count  int            `json:"count"`
+	Total  int            `json:"total"`
+	Movies []movieSummary `json:"movies"`
+}
+
+// moviesByYearHandler is GET /api/movies/year, the best titles from one
+// year, optionally of one genre. It is a genre crawl (an empty genre takes
+// everything the seed keywords find) whose searches pass OMDb the year, with
+// every detail checked again by spansYear since a series' Year is a range.
+//
+//	@Summary	Top movies of a year
+//	@Tags		movies
+//	@Produce	json,xml
+//	@Param		year	query		int		true	"Release year, e.g. 2019"
+//	@Param		genre	query		string	false	"Genre, e.g. Drama"
+//	@Param		limit	query		int		false	"Movies to return, at most 100"	default(15)
+//	@Param		sort	query		string	false	"Sort key"	Enums(rating, year, title)
+//	@Param		order	query		string	false	"Sort order"	Enums(asc, desc)
+//	@Param		type	query		string	false	"Restrict to a type"	Enums(movie, series, episode)
+//	@Success	200		{object}	yearResponse
+//	@Failure	400		{object}	errorResponse	"MISSING_PARAM, INVALID_PARAM"
+//	@Failure	429		{object}	errorResponse	"QUOTA_EXCEEDED"
+//	@Failure	503		{object}	errorResponse	"UPSTREAM_ERROR, while the OMDb circuit breaker is open"
+//	@Failure	504		{object}	errorResponse	"TIMEOUT"
+//	@Security	APIKey
+//	@Router		/api/movies/year [get]
+func moviesByYearHandler(c *gin.Context) {
+	if !requireParams(c, "year") {
+		return
+	}
+	v := c.Query("year")
+	if !yearPattern.MatchString(v) {
+		respondParamError(c, &paramError{"year", v, "a four digit year"})
+		return
+	}
+	year, _ := strconv.Atoi(v)
+	genre := c.Query("genre")
+	rank, sortBy, order, err := parseSort(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	limit := defaultGenreLimit
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondParamError(c, &paramError{"limit", l, "a positive integer"})
+			return
+		}
+		limit = min(n, maxGenreLimit)
+	}
+	typ, err := parseType(c)
+	if err != nil {
+		respondParamError(c, err)
+		return
+	}
+	keep := func(m *Movie) bool { return spansYear(m.Year, year) }
+	var stats collectStats
+	top := collectTopByGenre(c.Request.Context(), genre, limit, crawlOpts{limit: genreCrawlLimit, keep: keep, rank: rank, typ: typ, year: v, stats: &stats})
+	if timedOut(c) {
+		return
+	}
+	if stats.stopped != nil && len(top) == 0 {
+		respondFetchError(c, stats.stopped, "")
+		return
+	}
+	out := make([]movieSummary, 0, len(top))
+	for _, m := range top {
+		out = append(out, summarize(m))
+	}
+	respond(c, 200, yearResponse{Year: year, Genre: genre, Type: typ, Sort: sortBy, Order: order, Count: len(out), Total: stats.kept(), Movies: out})
+}